@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -17,6 +18,15 @@ func main() {
 	batteryCountStr := flag.String("batteryCount", "2", "the number of batteries in a power bank we want to calculate the joltage for")
 	filePath := flag.String("file", "", "path to the input file containing product ID ranges")
 	logLevel := flag.String("logLevel", "info", "log level for application")
+	algorithmFlag := flag.String("algorithm", "scan", "battery selection algorithm (scan or stack)")
+	invalidLinesFlag := flag.String("invalidLines", "fail", "how to treat invalid power banks (fail or skip)")
+	objectiveFlag := flag.String("objective", "max", "whether to look for the largest or smallest joltage (max or min)")
+	top := flag.Int("top", 0, "number of best power banks to report")
+	perBankCounts := flag.Bool("perBankCounts", false, "read the battery count of every power bank from a 'count:batteries' line prefix")
+	maxSkips := flag.Int("maxSkips", day03.NoSkipLimit, "maximum number of batteries that can be skipped between the first and last picked battery (-1 for no limit)")
+	workers := flag.Int("workers", 1, "number of goroutines processing the power banks")
+	timeout := flag.Duration("timeout", 10*time.Second, "maximum time to spend on solving the problem")
+	annotate := flag.Bool("annotate", false, "print every power bank with the chosen batteries highlighted")
 
 	flag.Parse()
 
@@ -24,6 +34,36 @@ func main() {
 		log.Fatalf("missing required flag: -file")
 	}
 
+	var algorithm day03.SelectionAlgorithm
+	switch *algorithmFlag {
+	case "scan":
+		algorithm = day03.SelectionGreedyScan
+	case "stack":
+		algorithm = day03.SelectionMonotonicStack
+	default:
+		log.Fatalf("incorrect flag of '%s' for algorithm, valid values are 'scan' or 'stack'", *algorithmFlag)
+	}
+
+	var invalidLineMode day03.InvalidLineMode
+	switch *invalidLinesFlag {
+	case "fail":
+		invalidLineMode = day03.InvalidLinesFail
+	case "skip":
+		invalidLineMode = day03.InvalidLinesSkip
+	default:
+		log.Fatalf("incorrect flag of '%s' for invalid lines, valid values are 'fail' or 'skip'", *invalidLinesFlag)
+	}
+
+	var objective day03.Objective
+	switch *objectiveFlag {
+	case "max":
+		objective = day03.ObjectiveMaximise
+	case "min":
+		objective = day03.ObjectiveMinimise
+	default:
+		log.Fatalf("incorrect flag of '%s' for objective, valid values are 'max' or 'min'", *objectiveFlag)
+	}
+
 	batteryCount, err := strconv.Atoi(*batteryCountStr)
 
 	if err != nil {
@@ -41,13 +81,33 @@ func main() {
 	logger := logging.NewLogger(*logLevel)
 	defer logger.Sync()
 
-	day3Solver, err := day03.NewDay3Solver(batteryCount, logger)
+	opts := []day03.Option{
+		day03.WithSelectionAlgorithm(algorithm),
+		day03.WithInvalidLineMode(invalidLineMode),
+		day03.WithObjective(objective),
+		day03.WithTopPowerBanks(*top),
+		day03.WithMaxSkips(*maxSkips),
+		day03.WithWorkers(*workers),
+		// the selections are streamed to the handler so large inputs do not have to fit into memory
+		day03.WithSelectionHandler(func(selection day03.BatterySelection) error {
+			if *annotate {
+				fmt.Printf("%s %s\n", selection.Annotate(), selection.Joltage)
+			}
+			return nil
+		}),
+	}
+
+	if *perBankCounts {
+		opts = append(opts, day03.WithPerBankCounts())
+	}
+
+	day3Solver, err := day03.NewDay3Solver(batteryCount, logger, opts...)
 
 	if err != nil {
 		logger.Fatal("failed to instantiate day 3 problem solver", zap.Error(err))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
 
 	solution, err := day3Solver.Solve(ctx, file)
@@ -56,5 +116,13 @@ func main() {
 		logger.Fatal("failed to run day 3 problem solver", zap.Error(err))
 	}
 
-	logger.Info("solved day 3 problem", zap.Int("solution", solution))
+	for rank, powerBank := range solution.TopPowerBanks {
+		logger.Info("top power bank",
+			zap.Int("rank", rank+1),
+			zap.Int("line", powerBank.Line),
+			zap.String("joltage", powerBank.Joltage.String()),
+		)
+	}
+
+	logger.Info("solved day 3 problem", zap.String("solution", solution.TotalJoltage.String()))
 }
@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"time"
 
 	"github.com/GabrielDCelery/advent-of-code-2025/internals/day04"
@@ -16,6 +20,19 @@ func main() {
 	removalModeFlag := flag.String("removalMode", "single", "removal mode of rolls after we reach them (single or recursive)")
 	filePath := flag.String("file", "", "path to the input file containing product ID ranges")
 	logLevel := flag.String("logLevel", "info", "log level for application")
+	threshold := flag.Int("threshold", day04.MinNeighborsForInaccessible, "a roll is reachable when it has fewer neighbouring rolls than this")
+	neighbourhoodFlag := flag.String("neighbourhood", "moore", "neighbourhood shape (moore, vonneumann, chebyshev:r, manhattan:r or custom:dx,dy;dx,dy)")
+	report := flag.Bool("report", false, "print every removal wave and the remaining rolls as JSON")
+	rollChars := flag.String("rollChars", "@", "characters of the input that are rolls")
+	emptyChars := flag.String("emptyChars", ".", "characters of the input that are empty cells")
+	animate := flag.String("animate", "", "animate the removal waves (ascii or gif)")
+	gifPath := flag.String("gifOut", "day04.gif", "path of the GIF written when animating with gif")
+	frameDelay := flag.Duration("frameDelay", 200*time.Millisecond, "time between two frames of the animation")
+	cellSize := flag.Int("cellSize", 4, "size of a grid cell in pixels when animating with gif")
+	edgeModeFlag := flag.String("edge", "walls", "how cells outside of the grid are treated (walls, wrap or filled)")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of goroutines counting the neighbours of the rolls")
+	gridFlag := flag.String("grid", "auto", "how the grid is stored in memory (auto, dense or sparse)")
+	timeout := flag.Duration("timeout", 10*time.Second, "maximum time to spend on solving the problem, 0 means no limit")
 
 	flag.Parse()
 
@@ -29,6 +46,46 @@ func main() {
 		log.Fatalf("incorrect flag of '%s' for removal mode, valid values are 'single' or 'recursive'", *removalModeFlag)
 	}
 
+	neighbourhood, err := day04.ParseNeighbourhood(*neighbourhoodFlag)
+
+	if err != nil {
+		log.Fatalf("incorrect flag of '%s' for neighbourhood: %v", *neighbourhoodFlag, err)
+	}
+
+	alphabet, err := day04.NewAlphabet(*rollChars, *emptyChars)
+
+	if err != nil {
+		log.Fatalf("incorrect flags for the alphabet: %v", err)
+	}
+
+	var edgeMode day04.EdgeMode
+	switch *edgeModeFlag {
+	case "walls":
+		edgeMode = day04.EdgeWalls
+	case "wrap":
+		edgeMode = day04.EdgeWrap
+	case "filled":
+		edgeMode = day04.EdgeFilled
+	default:
+		log.Fatalf("incorrect flag of '%s' for edge, valid values are 'walls', 'wrap' or 'filled'", *edgeModeFlag)
+	}
+
+	var representation day04.GridRepresentation
+	switch *gridFlag {
+	case "auto":
+		representation = day04.GridRepresentationAuto
+	case "dense":
+		representation = day04.GridRepresentationDense
+	case "sparse":
+		representation = day04.GridRepresentationSparse
+	default:
+		log.Fatalf("incorrect flag of '%s' for grid, valid values are 'auto', 'dense' or 'sparse'", *gridFlag)
+	}
+
+	if *animate != "" && *animate != "ascii" && *animate != "gif" {
+		log.Fatalf("incorrect flag of '%s' for animate, valid values are 'ascii' or 'gif'", *animate)
+	}
+
 	if *filePath == "" {
 		log.Fatalf("missing required flag: -file")
 	}
@@ -44,14 +101,31 @@ func main() {
 	logger := logging.NewLogger(*logLevel)
 	defer logger.Sync()
 
-	day3Solver, err := day04.NewDay4Solver(logger)
+	opts := []day04.Option{
+		day04.WithThreshold(*threshold),
+		day04.WithNeighbourhood(neighbourhood),
+		day04.WithEdgeMode(edgeMode),
+		day04.WithAlphabet(alphabet),
+		day04.WithGridRepresentation(representation),
+		day04.WithWorkers(*workers),
+	}
+
+	if *report || *animate != "" {
+		opts = append(opts, day04.WithWaveReport())
+	}
+
+	day3Solver, err := day04.NewDay4Solver(logger, opts...)
 
 	if err != nil {
 		logger.Fatal("failed to instantiate day 4 problem solver", zap.Error(err))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
 
 	solution, err := day3Solver.Solve(ctx, file, removalMode)
 
@@ -59,5 +133,35 @@ func main() {
 		logger.Fatal("failed to run day 4 problem solver", zap.Error(err))
 	}
 
-	logger.Info("solved day 4 problem", zap.Int("solution", solution))
+	if *report {
+		encoder := json.NewEncoder(os.Stdout)
+		if err := encoder.Encode(solution); err != nil {
+			logger.Fatal("failed to write day 4 removal report", zap.Error(err))
+		}
+	}
+
+	switch *animate {
+	case "ascii":
+		for _, frame := range day04.RenderFrames(solution.Grid, solution.Waves, true) {
+			// move the cursor home and clear the screen so every frame is drawn over the previous one
+			fmt.Print("\033[H\033[2J")
+			fmt.Print(frame)
+			time.Sleep(*frameDelay)
+		}
+	case "gif":
+		gifFile, err := os.Create(*gifPath)
+		if err != nil {
+			logger.Fatal("failed to create GIF file", zap.String("path", *gifPath), zap.Error(err))
+		}
+		options := day04.GIFOptions{CellSize: *cellSize, Delay: int(frameDelay.Milliseconds() / 10)}
+		renderErr := day04.RenderGIF(gifFile, solution.Grid, solution.Waves, options)
+		// the file is closed before exiting as logger.Fatal skips deferred calls, a half written GIF is removed
+		closeErr := gifFile.Close()
+		if err := errors.Join(renderErr, closeErr); err != nil {
+			_ = os.Remove(*gifPath)
+			logger.Fatal("failed to render GIF", zap.String("path", *gifPath), zap.Error(err))
+		}
+	}
+
+	logger.Info("solved day 4 problem", zap.Int("solution", solution.RemovedRolls), zap.Int("remainingRolls", solution.RemainingRolls))
 }
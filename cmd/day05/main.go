@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/GabrielDCelery/advent-of-code-2025/internals/day05"
@@ -13,11 +18,194 @@ import (
 )
 
 func main() {
-	filePath := flag.String("file", "", "path to the input file containing product ID ranges")
+	// the range analysis subcommands have flags of their own, without a subcommand the puzzle is solved
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "stats":
+			runStats(os.Args[2:])
+			return
+		}
+	}
+
+	filePath := flag.String("file", "", "path to the input file containing product ID ranges, with -serve '-' reads the ranges and the queries from stdin")
 	logLevel := flag.String("logLevel", "info", "log level for application")
+	coalesceAdjacent := flag.Bool("coalesceAdjacent", false, "also merge ranges that follow each other without a gap")
+	explain := flag.Bool("explain", false, "print which ranges make every ingredient fresh as JSON lines")
+	redundant := flag.Bool("redundant", false, "print the ranges that are fully covered by other ranges as JSON lines")
+	serve := flag.Bool("serve", false, "load the ranges of the file and answer the ingredient queries read from stdin line by line")
+	lookupFlag := flag.String("lookup", "binary", "how ingredients are matched against the ranges (binary or tree)")
 
 	flag.Parse()
 
+	var lookup day05.LookupStrategy
+	switch *lookupFlag {
+	case "binary":
+		lookup = day05.LookupBinarySearch
+	case "tree":
+		lookup = day05.LookupIntervalTree
+	default:
+		log.Fatalf("incorrect flag of '%s' for lookup, valid values are 'binary' or 'tree'", *lookupFlag)
+	}
+
+	if *filePath == "" {
+		log.Fatalf("missing required flag: -file")
+	}
+
+	var file io.Reader = os.Stdin
+
+	if !*serve || *filePath != "-" {
+		opened, err := os.Open(*filePath)
+
+		if err != nil {
+			log.Fatalf("failed to open file at path %s: %v", *filePath, err)
+		}
+
+		defer opened.Close()
+		file = opened
+	}
+
+	logger := logging.NewLogger(*logLevel)
+	defer logger.Sync()
+
+	opts := []day05.Option{day05.WithLookupStrategy(lookup)}
+
+	if *coalesceAdjacent {
+		opts = append(opts, day05.WithAdjacentCoalescing())
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	if *explain {
+		opts = append(opts, day05.WithExplanationHandler(func(explanation day05.IngredientExplanation) error {
+			return encoder.Encode(explanation)
+		}))
+	}
+
+	if *redundant {
+		opts = append(opts, day05.WithRedundantRangeReport())
+	}
+
+	solver, err := day05.NewDay5Solver(logger, opts...)
+
+	if err != nil {
+		logger.Fatal("failed to instantiate day 5 problem solver", zap.Error(err))
+	}
+
+	if *serve {
+		// serving has no deadline, it runs until stdin is closed or the process is interrupted
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		inventory, rest, consumedLines, err := solver.LoadInventory(ctx, file)
+
+		if err != nil {
+			logger.Fatal("failed to load day 5 inventory", zap.Error(err))
+		}
+
+		// when the ranges come from stdin as well the queries are whatever follows them
+		queries := io.Reader(os.Stdin)
+		linesBefore := 0
+		if file == os.Stdin {
+			queries = rest
+			linesBefore = consumedLines
+		}
+
+		last, err := solver.ServeQueries(ctx, inventory, queries, linesBefore, func(answer day05.QueryAnswer) error {
+			return encoder.Encode(answer)
+		})
+
+		if err != nil && !errors.Is(err, context.Canceled) {
+			logger.Fatal("failed to serve day 5 queries", zap.Error(err))
+		}
+
+		logger.Info("served day 5 queries", zap.Int("queryCount", last.QueryCount), zap.Int("freshCount", last.FreshCount))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	solution, err := solver.Solve(ctx, file)
+
+	if err != nil {
+		logger.Fatal("failed to run day 5 problem solver", zap.Error(err))
+	}
+
+	for _, source := range solution.RedundantRanges {
+		if err := encoder.Encode(source); err != nil {
+			logger.Fatal("failed to write redundant range", zap.Error(err))
+		}
+	}
+
+	logger.Info("solved day 5 problem", zap.Int("freshIngredientsCount", solution.FreshIngredientsCount), zap.Stringer("availableIngredientsCount", solution.AvailableIngredientsCount))
+}
+
+// runDiff prints the IDs that only one of two range files covers
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	firstPath := flags.String("a", "", "path to the first input file")
+	secondPath := flags.String("b", "", "path to the second input file")
+	logLevel := flags.String("logLevel", "info", "log level for application")
+
+	flags.Parse(args)
+
+	if *firstPath == "" || *secondPath == "" {
+		log.Fatalf("missing required flags: -a and -b")
+	}
+
+	first, err := os.Open(*firstPath)
+
+	if err != nil {
+		log.Fatalf("failed to open file at path %s: %v", *firstPath, err)
+	}
+
+	defer first.Close()
+
+	second, err := os.Open(*secondPath)
+
+	if err != nil {
+		log.Fatalf("failed to open file at path %s: %v", *secondPath, err)
+	}
+
+	defer second.Close()
+
+	logger := logging.NewLogger(*logLevel)
+	defer logger.Sync()
+
+	solver, err := day05.NewDay5Solver(logger)
+
+	if err != nil {
+		logger.Fatal("failed to instantiate day 5 problem solver", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	diff, err := solver.DiffRanges(ctx, first, second)
+
+	if err != nil {
+		logger.Fatal("failed to diff day 5 ranges", zap.Error(err))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diff); err != nil {
+		logger.Fatal("failed to write day 5 range diff", zap.Error(err))
+	}
+}
+
+// runStats prints how the ranges of a file cover the IDs
+func runStats(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	filePath := flags.String("file", "", "path to the input file containing product ID ranges")
+	logLevel := flags.String("logLevel", "info", "log level for application")
+	coalesceAdjacent := flags.Bool("coalesceAdjacent", false, "also merge ranges that follow each other without a gap")
+
+	flags.Parse(args)
+
 	if *filePath == "" {
 		log.Fatalf("missing required flag: -file")
 	}
@@ -33,7 +221,13 @@ func main() {
 	logger := logging.NewLogger(*logLevel)
 	defer logger.Sync()
 
-	solver, err := day05.NewDay5Solver(logger)
+	opts := []day05.Option{}
+
+	if *coalesceAdjacent {
+		opts = append(opts, day05.WithAdjacentCoalescing())
+	}
+
+	solver, err := day05.NewDay5Solver(logger, opts...)
 
 	if err != nil {
 		logger.Fatal("failed to instantiate day 5 problem solver", zap.Error(err))
@@ -42,11 +236,15 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	solution, err := solver.Solve(ctx, file)
+	stats, err := solver.RangeStats(ctx, file)
 
 	if err != nil {
-		logger.Fatal("failed to run day 5 problem solver", zap.Error(err))
+		logger.Fatal("failed to compute day 5 range statistics", zap.Error(err))
 	}
 
-	logger.Info("solved day 5 problem", zap.Int("freshIngredientsCount", solution.FreshIngredientsCount), zap.Int("availableIngredientsCount", solution.AvailableIngredientsCount))
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(stats); err != nil {
+		logger.Fatal("failed to write day 5 range statistics", zap.Error(err))
+	}
 }
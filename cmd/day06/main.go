@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/GabrielDCelery/advent-of-code-2025/internals/day06"
@@ -13,21 +15,32 @@ import (
 )
 
 func main() {
-	interpreterAsStr := flag.String("interpreter", "human", "puzzle interpreter method (human vs cephalophod)")
+	interpreterAsStr := flag.String("interpreter", "human", "puzzle interpreter method (human, cephalophod, rightToLeft, bottomToTop, diagonal or reversed)")
 	filePath := flag.String("file", "", "path to the input file containing product ID ranges")
 	logLevel := flag.String("logLevel", "info", "log level for application")
+	breakdown := flag.Bool("breakdown", false, "print the operator, columns, operands and result of every problem as JSON lines")
+	render := flag.Bool("render", false, "print the worksheet with the answer of every problem under its columns")
 
 	flag.Parse()
 
-	var interpreter day06.PuzzleInterpreter
+	interpreters := day06.NewInterpreterRegistry()
+	for name, interpreter := range map[string]day06.PuzzleInterpreter{
+		"human":       day06.HumanMath,
+		"cephalophod": day06.CephalopodMath,
+		"rightToLeft": day06.ColumnsRightToLeft{},
+		"bottomToTop": day06.ColumnsBottomToTop{},
+		"diagonal":    day06.Diagonals{},
+		"reversed":    day06.ReversedRows{},
+	} {
+		if err := interpreters.Register(name, interpreter); err != nil {
+			log.Fatalf("failed to register interpreter '%s': %v", name, err)
+		}
+	}
+
+	interpreter, ok := interpreters.Lookup(*interpreterAsStr)
 
-	switch *interpreterAsStr {
-	case "human":
-		interpreter = day06.HumanMath
-	case "cephalophod":
-		interpreter = day06.CephalopodMath
-	default:
-		log.Fatalf("incorrect flag '%s' for interpreter, valid values are 'human', 'cephalophod'", *interpreterAsStr)
+	if !ok {
+		log.Fatalf("incorrect flag '%s' for interpreter, valid values are '%s'", *interpreterAsStr, strings.Join(interpreters.Names(), "', '"))
 	}
 
 	if *filePath == "" {
@@ -54,11 +67,26 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	solution, err := solver.Solve(ctx, file, interpreter)
+	worksheet, err := solver.Breakdown(ctx, file, interpreter)
 
 	if err != nil {
 		logger.Fatal("failed to run day 6 problem solver", zap.Error(err))
 	}
 
-	logger.Info("solved day 6 problem", zap.Int("solution", solution))
+	if *breakdown {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, problem := range worksheet.Problems {
+			if err := encoder.Encode(problem); err != nil {
+				logger.Fatal("failed to write problem breakdown", zap.Error(err))
+			}
+		}
+	}
+
+	if *render {
+		if err := worksheet.Render(os.Stdout); err != nil {
+			logger.Fatal("failed to render worksheet", zap.Error(err))
+		}
+	}
+
+	logger.Info("solved day 6 problem", zap.Stringer("solution", worksheet.Total))
 }
@@ -3,74 +3,689 @@ package day03
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 )
 
+type SelectionAlgorithm int
+
+const (
+	SelectionGreedyScan SelectionAlgorithm = iota
+	SelectionMonotonicStack
+)
+
+type InvalidLineMode int
+
+const (
+	InvalidLinesFail InvalidLineMode = iota
+	InvalidLinesSkip
+)
+
+type Objective int
+
+const (
+	ObjectiveMaximise Objective = iota
+	ObjectiveMinimise
+)
+
+// prefers tells if battery a is strictly better than battery b for the objective
+func (o Objective) prefers(a byte, b byte) bool {
+	if o == ObjectiveMinimise {
+		return a < b
+	}
+	return a > b
+}
+
+// NoSkipLimit lets the batteries be picked from anywhere in the power bank
+const NoSkipLimit = -1
+
+// defaultProgressInterval is how many power banks are processed between two progress log entries
+const defaultProgressInterval = 100000
+
+// inFlightPerWorker bounds how many lines can be read ahead of the slowest unfinished line
+const inFlightPerWorker = 64
+
+// maxPowerBankBytes is the longest power bank that can be read, it leaves room for banks of millions of batteries
+const maxPowerBankBytes = 1 << 28
+
+// perBankCountSeparator splits the battery count from the batteries when every bank has its own count
+const perBankCountSeparator = ":"
+
 type Day3Solver struct {
-	logger       *zap.Logger
-	batteryCount int
+	logger           *zap.Logger
+	batteryCount     int
+	selectFunc       selectBatteriesFunc
+	invalidLineMode  InvalidLineMode
+	objective        Objective
+	topBanksCount    int
+	perBankCounts    bool
+	maxSkips         int
+	workers          int
+	progressInterval int
+	selectionHandler SelectionHandler
 }
 
-func NewDay3Solver(batteryCount int, logger *zap.Logger) (*Day3Solver, error) {
+// SelectionHandler receives the battery selection of every power bank in input order
+type SelectionHandler func(selection BatterySelection) error
+
+type Option func(*Day3Solver) error
+
+// WithSelectionAlgorithm picks the algorithm used to choose the batteries of a power bank
+func WithSelectionAlgorithm(algorithm SelectionAlgorithm) Option {
+	return func(d *Day3Solver) error {
+		switch algorithm {
+		case SelectionGreedyScan:
+			d.selectFunc = d.selectBatteriesWithScan
+		case SelectionMonotonicStack:
+			d.selectFunc = d.selectBatteriesWithStack
+		default:
+			return fmt.Errorf("unhandled selection algorithm %d", algorithm)
+		}
+		return nil
+	}
+}
+
+// WithInvalidLineMode decides whether invalid power banks make the solver fail or get skipped
+func WithInvalidLineMode(mode InvalidLineMode) Option {
+	return func(d *Day3Solver) error {
+		switch mode {
+		case InvalidLinesFail, InvalidLinesSkip:
+			d.invalidLineMode = mode
+		default:
+			return fmt.Errorf("unhandled invalid line mode %d", mode)
+		}
+		return nil
+	}
+}
+
+// WithObjective decides whether we are looking for the largest or the smallest joltage of a power bank
+func WithObjective(objective Objective) Option {
+	return func(d *Day3Solver) error {
+		switch objective {
+		case ObjectiveMaximise, ObjectiveMinimise:
+			d.objective = objective
+		default:
+			return fmt.Errorf("unhandled objective %d", objective)
+		}
+		return nil
+	}
+}
+
+// WithTopPowerBanks keeps track of the n power banks that have the best joltage for the objective
+func WithTopPowerBanks(n int) Option {
+	return func(d *Day3Solver) error {
+		if n < 0 {
+			return fmt.Errorf("number of top power banks can not be negative, got %d", n)
+		}
+		d.topBanksCount = n
+		return nil
+	}
+}
+
+// WithPerBankCounts reads the battery count of every power bank from a 'count:batteries' line prefix
+func WithPerBankCounts() Option {
+	return func(d *Day3Solver) error {
+		d.perBankCounts = true
+		return nil
+	}
+}
+
+// WithMaxSkips only allows at most m batteries to be skipped between the first and the last picked battery
+func WithMaxSkips(m int) Option {
+	return func(d *Day3Solver) error {
+		if m < 0 && m != NoSkipLimit {
+			return fmt.Errorf("max skips can not be negative, got %d", m)
+		}
+		d.maxSkips = m
+		return nil
+	}
+}
+
+// WithWorkers processes the power banks on n goroutines, results are still aggregated in input order
+func WithWorkers(n int) Option {
+	return func(d *Day3Solver) error {
+		if n < 1 {
+			return fmt.Errorf("number of workers has to be at least 1, got %d", n)
+		}
+		d.workers = n
+		return nil
+	}
+}
+
+// WithProgressInterval logs the progress after every n processed power banks, 0 turns progress logging off
+func WithProgressInterval(n int) Option {
+	return func(d *Day3Solver) error {
+		if n < 0 {
+			return fmt.Errorf("progress interval can not be negative, got %d", n)
+		}
+		d.progressInterval = n
+		return nil
+	}
+}
+
+// WithSelectionHandler hands every selection to the handler instead of keeping them on the solution,
+// this way the memory use does not grow with the size of the input
+func WithSelectionHandler(handler SelectionHandler) Option {
+	return func(d *Day3Solver) error {
+		d.selectionHandler = handler
+		return nil
+	}
+}
+
+func NewDay3Solver(batteryCount int, logger *zap.Logger, opts ...Option) (*Day3Solver, error) {
+	if batteryCount < 0 {
+		return nil, fmt.Errorf("battery count can not be negative, got %d", batteryCount)
+	}
 	if logger == nil {
 		logger = zap.NewNop()
 	}
 	day3Solver := &Day3Solver{
-		batteryCount: batteryCount,
-		logger:       logger,
+		batteryCount:     batteryCount,
+		logger:           logger,
+		invalidLineMode:  InvalidLinesFail,
+		objective:        ObjectiveMaximise,
+		topBanksCount:    0,
+		perBankCounts:    false,
+		maxSkips:         NoSkipLimit,
+		workers:          1,
+		progressInterval: defaultProgressInterval,
+		selectionHandler: nil,
+	}
+	day3Solver.selectFunc = day3Solver.selectBatteriesWithScan
+	for _, opt := range opts {
+		if err := opt(day3Solver); err != nil {
+			return nil, err
+		}
 	}
 	return day3Solver, nil
 }
 
-func (d *Day3Solver) Solve(ctx context.Context, reader io.Reader) (int, error) {
-	solution := 0
+type selectBatteriesFunc func(powerBank string, batteryCount int) (BatterySelection, error)
+
+// BatterySelection describes which batteries were switched on in a single power bank
+type BatterySelection struct {
+	Line      int
+	PowerBank string
+	Indices   []int
+	Digits    string
+	Joltage   *big.Int
+}
+
+// InvalidPowerBankError points at the line and the character offset (counted from 0) that made a power bank invalid
+type InvalidPowerBankError struct {
+	Line   int
+	Offset int
+	Reason string
+}
+
+func (e InvalidPowerBankError) Error() string {
+	return fmt.Sprintf("invalid power bank at line %d, offset %d: %s", e.Line, e.Offset, e.Reason)
+}
+
+type Solution struct {
+	TotalJoltage      *big.Int
+	PowerBanks        []BatterySelection
+	TopPowerBanks     []BatterySelection
+	InvalidPowerBanks []InvalidPowerBankError
+}
+
+func (d *Day3Solver) Solve(ctx context.Context, reader io.Reader) (Solution, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	solution := Solution{
+		TotalJoltage:      new(big.Int),
+		PowerBanks:        []BatterySelection{},
+		TopPowerBanks:     []BatterySelection{},
+		InvalidPowerBanks: []InvalidPowerBankError{},
+	}
+
+	var err error
+	if d.workers > 1 {
+		err = d.solveInParallel(ctx, reader, &solution)
+	} else {
+		err = d.solveSequentially(ctx, reader, &solution)
+	}
+	if err != nil {
+		return Solution{}, err
+	}
+
+	if d.invalidLineMode == InvalidLinesFail && len(solution.InvalidPowerBanks) > 0 {
+		errs := make([]error, 0, len(solution.InvalidPowerBanks))
+		for _, invalid := range solution.InvalidPowerBanks {
+			errs = append(errs, invalid)
+		}
+		return Solution{}, errors.Join(errs...)
+	}
+	return solution, nil
+}
+
+type powerBankLine struct {
+	lineNumber int
+	line       string
+}
+
+type powerBankResult struct {
+	lineNumber int
+	selection  BatterySelection
+	invalid    InvalidPowerBankError
+	isValid    bool
+	isSkipped  bool
+	err        error
+}
+
+// newPowerBankScanner reads the input line by line, the buffer starts at the default size and only grows
+// for power banks that are longer than that
+func newPowerBankScanner(reader io.Reader) *bufio.Scanner {
 	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxPowerBankBytes)
+	return scanner
+}
+
+func (d *Day3Solver) solveSequentially(ctx context.Context, reader io.Reader, solution *Solution) error {
+	scanner := newPowerBankScanner(reader)
+	lineNumber := 0
 	for scanner.Scan() {
-		line := scanner.Text()
-		largestPossibleJoltage, err := d.getLargesPossibleJoltage(line, d.batteryCount)
-		if err != nil {
-			return 0, err
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped processing power banks at line %d: %w", lineNumber, ctx.Err())
+		default:
 		}
-		d.logger.Debug("got largest joltage for power bank",
-			zap.Int("joltage", largestPossibleJoltage),
-			zap.String("powerBank", line),
+		lineNumber++
+		// once we know the input is going to be rejected there is no point selecting batteries,
+		// we only keep going to report every invalid line in one go
+		skipSelection := d.invalidLineMode == InvalidLinesFail && len(solution.InvalidPowerBanks) > 0
+		result := d.processPowerBank(powerBankLine{lineNumber: lineNumber, line: scanner.Text()}, skipSelection)
+		if err := d.collect(solution, result); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read power banks: %w", err)
+	}
+	return nil
+}
+
+// solveInParallel reads the lines on one goroutine, fans them out to the workers and puts the results
+// back into input order before collecting them, so the solution does not depend on scheduling
+func (d *Day3Solver) solveInParallel(ctx context.Context, reader io.Reader, solution *Solution) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan powerBankLine, d.workers)
+	results := make(chan powerBankResult, d.workers)
+	// every line holds a slot until it has been collected, this stops a slow line from making
+	// the reordering buffer grow without limits
+	slots := make(chan struct{}, d.workers*inFlightPerWorker)
+
+	var sawInvalid atomic.Bool
+	var readErr error
+
+	go func() {
+		defer close(lines)
+		scanner := newPowerBankScanner(reader)
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				readErr = ctx.Err()
+				return
+			}
+			select {
+			case lines <- powerBankLine{lineNumber: lineNumber, line: scanner.Text()}:
+			case <-ctx.Done():
+				readErr = ctx.Err()
+				return
+			}
+		}
+		// only read by the collector after results got closed, which happens after lines got closed
+		readErr = scanner.Err()
+	}()
+
+	var wg sync.WaitGroup
+	for range d.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for line := range lines {
+				skipSelection := d.invalidLineMode == InvalidLinesFail && sawInvalid.Load()
+				result := d.processPowerBank(line, skipSelection)
+				if !result.isValid {
+					sawInvalid.Store(true)
+				}
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]powerBankResult)
+	nextLineNumber := 1
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped processing power banks at line %d: %w", nextLineNumber, ctx.Err())
+		case result, ok := <-results:
+			if !ok {
+				// the reader and the workers also stop when the context is done, in which case results
+				// got closed before every line was collected and the total is only partial
+				if err := ctx.Err(); err != nil {
+					return fmt.Errorf("stopped processing power banks at line %d: %w", nextLineNumber, err)
+				}
+				if readErr != nil {
+					return fmt.Errorf("failed to read power banks: %w", readErr)
+				}
+				return nil
+			}
+			pending[result.lineNumber] = result
+			for {
+				next, found := pending[nextLineNumber]
+				if !found {
+					break
+				}
+				delete(pending, nextLineNumber)
+				nextLineNumber++
+				<-slots
+				if err := d.collect(solution, next); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (d *Day3Solver) processPowerBank(line powerBankLine, skipSelection bool) powerBankResult {
+	powerBank, batteryCount, invalid, ok := d.parsePowerBank(line.line, line.lineNumber)
+	if !ok {
+		return powerBankResult{lineNumber: line.lineNumber, invalid: invalid, isValid: false}
+	}
+	if skipSelection {
+		return powerBankResult{lineNumber: line.lineNumber, isValid: true, isSkipped: true}
+	}
+	selection, err := d.selectBatteries(powerBank, batteryCount)
+	if err != nil {
+		return powerBankResult{lineNumber: line.lineNumber, isValid: true, err: err}
+	}
+	selection.Line = line.lineNumber
+	return powerBankResult{lineNumber: line.lineNumber, selection: selection, isValid: true}
+}
+
+// collect adds the result of a single power bank to the solution, results have to arrive in input order
+func (d *Day3Solver) collect(solution *Solution, result powerBankResult) error {
+	if d.progressInterval > 0 && result.lineNumber%d.progressInterval == 0 {
+		d.logger.Info("processed power banks",
+			zap.Int("lines", result.lineNumber),
+			zap.Stringer("totalJoltage", solution.TotalJoltage),
 		)
-		solution += largestPossibleJoltage
 	}
-	return solution, nil
+	if result.err != nil {
+		return fmt.Errorf("failed to select batteries at line %d: %w", result.lineNumber, result.err)
+	}
+	if !result.isValid {
+		d.logger.Warn("found invalid power bank",
+			zap.Int("line", result.invalid.Line),
+			zap.Int("offset", result.invalid.Offset),
+			zap.String("reason", result.invalid.Reason),
+		)
+		solution.InvalidPowerBanks = append(solution.InvalidPowerBanks, result.invalid)
+		return nil
+	}
+	if result.isSkipped || (d.invalidLineMode == InvalidLinesFail && len(solution.InvalidPowerBanks) > 0) {
+		return nil
+	}
+	selection := result.selection
+	d.logger.Debug("got joltage for power bank",
+		zap.Stringer("joltage", selection.Joltage),
+		zap.Ints("indices", selection.Indices),
+		zap.String("powerBank", selection.PowerBank),
+	)
+	solution.TotalJoltage.Add(solution.TotalJoltage, selection.Joltage)
+	solution.TopPowerBanks = d.rankPowerBank(solution.TopPowerBanks, selection)
+	if d.selectionHandler != nil {
+		return d.selectionHandler(selection)
+	}
+	solution.PowerBanks = append(solution.PowerBanks, selection)
+	return nil
+}
+
+// parsePowerBank strips the optional battery count prefix from the line and validates what is left
+func (d *Day3Solver) parsePowerBank(line string, lineNumber int) (string, int, InvalidPowerBankError, bool) {
+	if !d.perBankCounts {
+		invalid, ok := validatePowerBank(line, lineNumber, d.batteryCount, 0)
+		return line, d.batteryCount, invalid, ok
+	}
+	countAsStr, powerBank, found := strings.Cut(line, perBankCountSeparator)
+	if !found {
+		return "", 0, InvalidPowerBankError{
+			Line:   lineNumber,
+			Offset: 0,
+			Reason: fmt.Sprintf("missing '%s' separated battery count prefix", perBankCountSeparator),
+		}, false
+	}
+	batteryCount, err := strconv.Atoi(countAsStr)
+	if err != nil || batteryCount < 0 {
+		return "", 0, InvalidPowerBankError{
+			Line:   lineNumber,
+			Offset: 0,
+			Reason: fmt.Sprintf("battery count '%s' has to be a non negative integer", countAsStr),
+		}, false
+	}
+	prefixLen := len([]rune(countAsStr)) + len(perBankCountSeparator)
+	invalid, ok := validatePowerBank(powerBank, lineNumber, batteryCount, prefixLen)
+	return powerBank, batteryCount, invalid, ok
+}
+
+func validatePowerBank(powerBank string, lineNumber int, batteryCount int, baseOffset int) (InvalidPowerBankError, bool) {
+	offset := 0
+	for _, char := range powerBank {
+		if char < '0' || char > '9' {
+			return InvalidPowerBankError{
+				Line:   lineNumber,
+				Offset: baseOffset + offset,
+				Reason: fmt.Sprintf("battery %q is not a digit", char),
+			}, false
+		}
+		offset++
+	}
+	if batteryCount > offset {
+		return InvalidPowerBankError{
+			Line:   lineNumber,
+			Offset: baseOffset + offset,
+			Reason: fmt.Sprintf("battery count %d is greater than the size of power bank %d", batteryCount, offset),
+		}, false
+	}
+	return InvalidPowerBankError{}, true
+}
+
+// rankPowerBank keeps the top power banks ordered from best to worst, on equal joltage the earlier line wins
+func (d *Day3Solver) rankPowerBank(ranked []BatterySelection, selection BatterySelection) []BatterySelection {
+	if d.topBanksCount == 0 {
+		return ranked
+	}
+	pos := sort.Search(len(ranked), func(i int) bool {
+		cmp := selection.Joltage.Cmp(ranked[i].Joltage)
+		if d.objective == ObjectiveMinimise {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+	if pos >= d.topBanksCount {
+		return ranked
+	}
+	ranked = append(ranked, BatterySelection{})
+	copy(ranked[pos+1:], ranked[pos:])
+	ranked[pos] = selection
+	if len(ranked) > d.topBanksCount {
+		ranked = ranked[:d.topBanksCount]
+	}
+	return ranked
+}
+
+// selectBatteries applies the skip limit on top of the selection algorithm by trying every window
+// that is short enough to not skip more than the allowed number of batteries
+//
+// NOTE: Time complexity is O(n) times the cost of the selection algorithm when there is a skip limit
+func (d *Day3Solver) selectBatteries(powerBank string, batteryCount int) (BatterySelection, error) {
+	if d.maxSkips == NoSkipLimit || len(powerBank)-batteryCount <= d.maxSkips {
+		return d.selectFunc(powerBank, batteryCount)
+	}
+
+	windowSize := batteryCount + d.maxSkips
+	var best BatterySelection
+	found := false
+
+	for start := 0; start+batteryCount <= len(powerBank); start++ {
+		end := min(start+windowSize, len(powerBank))
+		candidate, err := d.selectFunc(powerBank[start:end], batteryCount)
+		if err != nil {
+			return BatterySelection{}, err
+		}
+		// every candidate has the same number of digits so comparing them as strings is the same as comparing joltages
+		isBetter := !found ||
+			(d.objective == ObjectiveMaximise && candidate.Digits > best.Digits) ||
+			(d.objective == ObjectiveMinimise && candidate.Digits < best.Digits)
+		if !isBetter {
+			continue
+		}
+		for i := range candidate.Indices {
+			candidate.Indices[i] += start
+		}
+		candidate.PowerBank = powerBank
+		best = candidate
+		found = true
+	}
+
+	return best, nil
 }
 
 // NOTE: Time complexity O(n * k) time complexity solution
-// space complexiy is O(1)
-func (d *Day3Solver) getLargesPossibleJoltage(powerBank string, batteryCount int) (int, error) {
+// space complexiy is O(k) for storing the picked positions
+func (d *Day3Solver) selectBatteriesWithScan(powerBank string, batteryCount int) (BatterySelection, error) {
 	if batteryCount > len(powerBank) {
-		return 0, fmt.Errorf("battery count %d is greater than the size of power bank %d", batteryCount, len(powerBank))
+		return BatterySelection{}, fmt.Errorf("battery count %d is greater than the size of power bank %d", batteryCount, len(powerBank))
 	}
 
-	sum := 0
 	lastPickedPos := -1
+	indices := make([]int, 0, batteryCount)
 
 	for batteryIdx := range batteryCount {
 		minPos := lastPickedPos + 1
 		maxPos := len(powerBank) - batteryCount + batteryIdx
 
-		bestDigit := -1
-		bestPos := -1
+		bestPos := minPos
 
-		for pos := minPos; pos <= maxPos; pos++ {
-			digit := int(powerBank[pos] - '0')
-			if digit > bestDigit {
-				bestDigit = digit
+		for pos := minPos + 1; pos <= maxPos; pos++ {
+			if d.objective.prefers(powerBank[pos], powerBank[bestPos]) {
 				bestPos = pos
 			}
 		}
 
-		sum = sum*10 + bestDigit
 		lastPickedPos = bestPos
+		indices = append(indices, bestPos)
+	}
+
+	return newBatterySelection(powerBank, indices)
+}
+
+// NOTE: Time complexity O(n) as every battery is pushed and popped at most once
+// space complexity is O(n) for the stack
+func (d *Day3Solver) selectBatteriesWithStack(powerBank string, batteryCount int) (BatterySelection, error) {
+	if batteryCount > len(powerBank) {
+		return BatterySelection{}, fmt.Errorf("battery count %d is greater than the size of power bank %d", batteryCount, len(powerBank))
+	}
+
+	// we can afford to leave out this many batteries, every pop spends one of them
+	dropsLeft := len(powerBank) - batteryCount
+	stack := make([]int, 0, len(powerBank))
+
+	for pos := 0; pos < len(powerBank); pos++ {
+		// only pop on strictly worse digits so that on ties the leftmost battery is kept,
+		// this matches what the greedy scan picks
+		for dropsLeft > 0 && len(stack) > 0 && d.objective.prefers(powerBank[pos], powerBank[stack[len(stack)-1]]) {
+			stack = stack[:len(stack)-1]
+			dropsLeft--
+		}
+		stack = append(stack, pos)
 	}
 
-	return sum, nil
+	return newBatterySelection(powerBank, stack[:batteryCount])
+}
+
+// maxInt64Digits is the longest run of decimal digits that is guaranteed to fit into an int64
+const maxInt64Digits = 18
+
+func newBatterySelection(powerBank string, indices []int) (BatterySelection, error) {
+	var builder strings.Builder
+	for _, idx := range indices {
+		builder.WriteByte(powerBank[idx])
+	}
+	digits := builder.String()
+	joltage, err := digitsToJoltage(digits)
+	if err != nil {
+		return BatterySelection{}, err
+	}
+	return BatterySelection{
+		PowerBank: powerBank,
+		Indices:   indices,
+		Digits:    digits,
+		Joltage:   joltage,
+	}, nil
+}
+
+// digitsToJoltage only falls back to arbitrary precision parsing when the digits could overflow an int64
+func digitsToJoltage(digits string) (*big.Int, error) {
+	if len(digits) > maxInt64Digits {
+		joltage, ok := new(big.Int).SetString(digits, 10)
+		if !ok {
+			return nil, fmt.Errorf("joltage '%s' is not a valid integer", digits)
+		}
+		return joltage, nil
+	}
+	var sum int64
+	for i := 0; i < len(digits); i++ {
+		sum = sum*10 + int64(digits[i]-'0')
+	}
+	return big.NewInt(sum), nil
+}
+
+const (
+	highlightStart = "\033[1;32m"
+	highlightEnd   = "\033[0m"
+)
+
+// Annotate returns the power bank with the chosen batteries highlighted using ANSI escape codes
+func (b BatterySelection) Annotate() string {
+	var builder strings.Builder
+	next := 0
+	for pos := 0; pos < len(b.PowerBank); pos++ {
+		if next < len(b.Indices) && b.Indices[next] == pos {
+			builder.WriteString(highlightStart)
+			builder.WriteByte(b.PowerBank[pos])
+			builder.WriteString(highlightEnd)
+			next++
+			continue
+		}
+		builder.WriteByte(b.PowerBank[pos])
+	}
+	return builder.String()
 }
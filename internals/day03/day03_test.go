@@ -3,15 +3,30 @@ package day03
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"math/rand/v2"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 )
 
-func TestDay3Solver_getLargestPossibleJoltage(t *testing.T) {
+func TestNewDay3Solver(t *testing.T) {
+	t.Run("Rejects a negative battery count", func(t *testing.T) {
+		t.Parallel()
+		//when
+		_, err := NewDay3Solver(-1, nil)
+
+		//then
+		assert.EqualError(t, err, "battery count can not be negative, got -1")
+	})
+}
+
+func TestDay3Solver_selectBatteriesWithScan(t *testing.T) {
 	t.Parallel()
 
 	testCases := []struct {
@@ -39,14 +54,106 @@ func TestDay3Solver_getLargestPossibleJoltage(t *testing.T) {
 			day3Solver, _ := NewDay3Solver(tt.batteryCount, logger)
 
 			//when
-			result, err := day3Solver.getLargesPossibleJoltage(tt.poweBank, tt.batteryCount)
+			result, err := day3Solver.selectBatteriesWithScan(tt.poweBank, tt.batteryCount)
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprint(tt.expected), result.Joltage.String())
+		})
+	}
+
+}
+
+func TestDay3Solver_selectBatteriesWithScan_selection(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		poweBank        string
+		batteryCount    int
+		expectedIndices []int
+		expectedDigits  string
+	}{
+		{poweBank: "987654321111111", batteryCount: 2, expectedIndices: []int{0, 1}, expectedDigits: "98"},
+		{poweBank: "811111111111119", batteryCount: 2, expectedIndices: []int{0, 14}, expectedDigits: "89"},
+		{poweBank: "818181911112111", batteryCount: 2, expectedIndices: []int{6, 11}, expectedDigits: "92"},
+		{poweBank: "234234234234278", batteryCount: 12, expectedIndices: []int{2, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, expectedDigits: "434234234278"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Picks batteries %v from power bank %s with battery count %d", tt.expectedIndices, tt.poweBank, tt.batteryCount), func(t *testing.T) {
+			t.Parallel()
+			//given
+			day3Solver, _ := NewDay3Solver(tt.batteryCount, nil)
+
+			//when
+			result, err := day3Solver.selectBatteriesWithScan(tt.poweBank, tt.batteryCount)
 
 			//then
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expectedIndices, result.Indices)
+			assert.Equal(t, tt.expectedDigits, result.Digits)
+			assert.Equal(t, tt.poweBank, result.PowerBank)
 		})
 	}
 
+	t.Run("Highlights the picked batteries when annotating", func(t *testing.T) {
+		t.Parallel()
+		//given
+		selection := BatterySelection{PowerBank: "8181", Indices: []int{0, 2}, Digits: "88", Joltage: big.NewInt(88)}
+
+		//when
+		result := selection.Annotate()
+
+		//then
+		assert.Equal(t, highlightStart+"8"+highlightEnd+"1"+highlightStart+"8"+highlightEnd+"1", result)
+	})
+}
+
+func TestDay3Solver_selectBatteriesWithStack(t *testing.T) {
+	t.Parallel()
+
+	for _, objective := range []Objective{ObjectiveMaximise, ObjectiveMinimise} {
+		t.Run(fmt.Sprintf("Gives identical results to the greedy scan on random power banks for objective %d", objective), func(t *testing.T) {
+			t.Parallel()
+			//given
+			day3Solver, _ := NewDay3Solver(0, nil, WithObjective(objective))
+			random := rand.New(rand.NewPCG(2025, uint64(3+objective)))
+
+			for range 500 {
+				bankSize := 1 + random.IntN(2000)
+				// narrow digit alphabets produce lots of ties which is where the two algorithms could diverge
+				digitRange := 1 + random.IntN(10)
+				var builder strings.Builder
+				for range bankSize {
+					builder.WriteByte(byte('0' + random.IntN(digitRange)))
+				}
+				powerBank := builder.String()
+				batteryCount := 1 + random.IntN(bankSize)
+
+				//when
+				expected, errScan := day3Solver.selectBatteriesWithScan(powerBank, batteryCount)
+				result, errStack := day3Solver.selectBatteriesWithStack(powerBank, batteryCount)
+
+				//then
+				assert.NoError(t, errScan)
+				assert.NoError(t, errStack)
+				assert.Equal(t, expected.Indices, result.Indices, "power bank %s with battery count %d", powerBank, batteryCount)
+				assert.Equal(t, expected.Digits, result.Digits, "power bank %s with battery count %d", powerBank, batteryCount)
+			}
+		})
+	}
+
+	t.Run("Returns an error when the battery count is greater than the size of power bank", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(0, nil)
+
+		//when
+		_, err := day3Solver.selectBatteriesWithStack("123", 4)
+
+		//then
+		assert.Error(t, err)
+	})
 }
 
 func TestDay3Solver_Solve(t *testing.T) {
@@ -63,20 +170,378 @@ func TestDay3Solver_Solve(t *testing.T) {
 		}
 
 		for _, tt := range testCases {
-			t.Run(fmt.Sprintf("returns %d for battery count %d", tt.expected, tt.batteryCount), func(t *testing.T) {
+			for _, algorithm := range []SelectionAlgorithm{SelectionGreedyScan, SelectionMonotonicStack} {
+				t.Run(fmt.Sprintf("returns %d for battery count %d using algorithm %d", tt.expected, tt.batteryCount, algorithm), func(t *testing.T) {
+					//given
+					logger := zaptest.NewLogger(t, zaptest.Level(zapcore.DebugLevel))
+					defer logger.Sync()
+					day3Solver, errSolver := NewDay3Solver(tt.batteryCount, logger, WithSelectionAlgorithm(algorithm))
+
+					//when
+					result, err := day3Solver.Solve(context.Background(), strings.NewReader(tt.input))
+
+					//then
+					assert.NoError(t, errSolver)
+					assert.NoError(t, err)
+					assert.Equal(t, fmt.Sprint(tt.expected), result.TotalJoltage.String())
+					assert.Len(t, result.PowerBanks, 4)
+				})
+			}
+		}
+
+	})
+}
+
+func TestDay3Solver_Solve_largeBatteryCounts(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input        string
+		batteryCount int
+		expected     string
+	}{
+		{input: "9999999999999999999", batteryCount: 19, expected: "9999999999999999999"},
+		{input: "1234567890123456789012345", batteryCount: 20, expected: "67890123456789012345"},
+		{input: "98765432109876543210987654321\n12345678901234567890123456789", batteryCount: 25, expected: "15555451111111101111111110"},
+	}
+
+	for _, tt := range testCases {
+		for _, algorithm := range []SelectionAlgorithm{SelectionGreedyScan, SelectionMonotonicStack} {
+			t.Run(fmt.Sprintf("returns %s for battery count %d using algorithm %d without overflowing", tt.expected, tt.batteryCount, algorithm), func(t *testing.T) {
+				t.Parallel()
 				//given
-				logger := zaptest.NewLogger(t, zaptest.Level(zapcore.DebugLevel))
-				defer logger.Sync()
-				day3Solver, _ := NewDay3Solver(tt.batteryCount, logger)
+				day3Solver, _ := NewDay3Solver(tt.batteryCount, nil, WithSelectionAlgorithm(algorithm))
 
 				//when
 				result, err := day3Solver.Solve(context.Background(), strings.NewReader(tt.input))
 
 				//then
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expected, result)
+				assert.Equal(t, tt.expected, result.TotalJoltage.String())
 			})
 		}
+	}
+}
+
+func TestDay3Solver_Solve_longPowerBanks(t *testing.T) {
+	t.Parallel()
+
+	// the power bank is longer than the default 64KiB line limit of a scanner
+	input := "9" + strings.Repeat("1", 70000) + "8\n12\n"
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("Reads power banks longer than 64KiB with %d workers", workers), func(t *testing.T) {
+			t.Parallel()
+			//given
+			day3Solver, _ := NewDay3Solver(2, nil, WithWorkers(workers))
 
+			//when
+			result, err := day3Solver.Solve(context.Background(), strings.NewReader(input))
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, "110", result.TotalJoltage.String())
+		})
+	}
+}
+
+func TestDay3Solver_Solve_invalidPowerBanks(t *testing.T) {
+	t.Parallel()
+
+	input := "987654321111111\n8111a1111111119\n\n2342342\r34234278\n818181911112111"
+
+	t.Run("Reports every invalid power bank with line number and offset when failing", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(2, nil)
+
+		//when
+		_, err := day3Solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.ErrorContains(t, err, "line 2, offset 4")
+		assert.ErrorContains(t, err, "line 3, offset 0")
+		assert.ErrorContains(t, err, "line 4, offset 7")
+		var invalid InvalidPowerBankError
+		assert.ErrorAs(t, err, &invalid)
 	})
+
+	t.Run("Skips invalid power banks and sums up the rest", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(2, nil, WithInvalidLineMode(InvalidLinesSkip))
+
+		//when
+		result, err := day3Solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, "190", result.TotalJoltage.String())
+		assert.Equal(t, []InvalidPowerBankError{
+			{Line: 2, Offset: 4, Reason: "battery 'a' is not a digit"},
+			{Line: 3, Offset: 0, Reason: "battery count 2 is greater than the size of power bank 0"},
+			{Line: 4, Offset: 7, Reason: "battery '\\r' is not a digit"},
+		}, result.InvalidPowerBanks)
+	})
+}
+
+func TestDay3Solver_Solve_objectives(t *testing.T) {
+	t.Parallel()
+
+	input := "987654321111111\n811111111111119\n234234234234278\n818181911112111"
+
+	t.Run("Calculates the smallest possible joltage", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(2, nil, WithObjective(ObjectiveMinimise))
+
+		//when
+		result, err := day3Solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"11", "11", "22", "11"}, []string{
+			result.PowerBanks[0].Digits,
+			result.PowerBanks[1].Digits,
+			result.PowerBanks[2].Digits,
+			result.PowerBanks[3].Digits,
+		})
+		assert.Equal(t, "55", result.TotalJoltage.String())
+	})
+
+	t.Run("Ranks the top power banks by joltage", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(2, nil, WithTopPowerBanks(2))
+
+		//when
+		result, err := day3Solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Len(t, result.TopPowerBanks, 2)
+		assert.Equal(t, 1, result.TopPowerBanks[0].Line)
+		assert.Equal(t, "98", result.TopPowerBanks[0].Digits)
+		assert.Equal(t, 4, result.TopPowerBanks[1].Line)
+		assert.Equal(t, "92", result.TopPowerBanks[1].Digits)
+	})
+
+	t.Run("Ranks the top power banks by smallest joltage and keeps the earlier line on ties", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(2, nil, WithObjective(ObjectiveMinimise), WithTopPowerBanks(3))
+
+		//when
+		result, err := day3Solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 4}, []int{result.TopPowerBanks[0].Line, result.TopPowerBanks[1].Line, result.TopPowerBanks[2].Line})
+	})
+
+	t.Run("Reads the battery count of every power bank from the line prefix", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(0, nil, WithPerBankCounts())
+
+		//when
+		result, err := day3Solver.Solve(context.Background(), strings.NewReader("2:987654321111111\n12:811111111111119\n1:234234234234278"))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, "98", result.PowerBanks[0].Digits)
+		assert.Equal(t, "811111111119", result.PowerBanks[1].Digits)
+		assert.Equal(t, "8", result.PowerBanks[2].Digits)
+		assert.Equal(t, "811111111225", result.TotalJoltage.String())
+	})
+
+	t.Run("Reports the offset of invalid batteries after the battery count prefix", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(0, nil, WithPerBankCounts())
+
+		//when
+		_, err := day3Solver.Solve(context.Background(), strings.NewReader("2:98x\n98\nx:98"))
+
+		//then
+		assert.ErrorContains(t, err, "line 1, offset 4")
+		assert.ErrorContains(t, err, "line 2, offset 0: missing ':' separated battery count prefix")
+		assert.ErrorContains(t, err, "line 3, offset 0: battery count 'x'")
+	})
+
+	t.Run("Limits how many batteries can be skipped between the first and last picked battery", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := []struct {
+			powerBank       string
+			maxSkips        int
+			objective       Objective
+			expectedDigits  string
+			expectedIndices []int
+		}{
+			{powerBank: "9119", maxSkips: NoSkipLimit, objective: ObjectiveMaximise, expectedDigits: "99", expectedIndices: []int{0, 3}},
+			{powerBank: "9119", maxSkips: 2, objective: ObjectiveMaximise, expectedDigits: "99", expectedIndices: []int{0, 3}},
+			{powerBank: "9119", maxSkips: 1, objective: ObjectiveMaximise, expectedDigits: "91", expectedIndices: []int{0, 1}},
+			{powerBank: "9119", maxSkips: 0, objective: ObjectiveMaximise, expectedDigits: "91", expectedIndices: []int{0, 1}},
+			{powerBank: "1991", maxSkips: 0, objective: ObjectiveMaximise, expectedDigits: "99", expectedIndices: []int{1, 2}},
+			{powerBank: "1881", maxSkips: 2, objective: ObjectiveMinimise, expectedDigits: "11", expectedIndices: []int{0, 3}},
+			{powerBank: "1881", maxSkips: 1, objective: ObjectiveMinimise, expectedDigits: "18", expectedIndices: []int{0, 1}},
+		}
+
+		for _, tt := range testCases {
+			for _, algorithm := range []SelectionAlgorithm{SelectionGreedyScan, SelectionMonotonicStack} {
+				t.Run(fmt.Sprintf("picks %s from %s with at most %d skips using algorithm %d", tt.expectedDigits, tt.powerBank, tt.maxSkips, algorithm), func(t *testing.T) {
+					t.Parallel()
+					//given
+					day3Solver, _ := NewDay3Solver(2, nil, WithMaxSkips(tt.maxSkips), WithObjective(tt.objective), WithSelectionAlgorithm(algorithm))
+
+					//when
+					result, err := day3Solver.Solve(context.Background(), strings.NewReader(tt.powerBank))
+
+					//then
+					assert.NoError(t, err)
+					assert.Equal(t, tt.expectedDigits, result.PowerBanks[0].Digits)
+					assert.Equal(t, tt.expectedIndices, result.PowerBanks[0].Indices)
+					assert.Equal(t, tt.powerBank, result.PowerBanks[0].PowerBank)
+				})
+			}
+		}
+	})
+}
+
+func TestDay3Solver_Solve_parallel(t *testing.T) {
+	t.Parallel()
+
+	random := rand.New(rand.NewPCG(2025, 31))
+	var builder strings.Builder
+	for range 5000 {
+		for range 100 {
+			builder.WriteByte(byte('0' + random.IntN(10)))
+		}
+		builder.WriteByte('\n')
+	}
+	input := builder.String()
+
+	t.Run("Gives the same solution in the same order as the sequential solver", func(t *testing.T) {
+		t.Parallel()
+		//given
+		sequentialSolver, _ := NewDay3Solver(12, nil, WithTopPowerBanks(5))
+		parallelSolver, errSolver := NewDay3Solver(12, nil, WithTopPowerBanks(5), WithWorkers(8))
+
+		//when
+		expected, errSequential := sequentialSolver.Solve(context.Background(), strings.NewReader(input))
+		result, errParallel := parallelSolver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, errSolver)
+		assert.NoError(t, errSequential)
+		assert.NoError(t, errParallel)
+		assert.Equal(t, expected.TotalJoltage.String(), result.TotalJoltage.String())
+		assert.Equal(t, expected.PowerBanks, result.PowerBanks)
+		assert.Equal(t, expected.TopPowerBanks, result.TopPowerBanks)
+	})
+
+	t.Run("Hands selections to the handler in input order without keeping them", func(t *testing.T) {
+		t.Parallel()
+		//given
+		lines := []int{}
+		handler := func(selection BatterySelection) error {
+			lines = append(lines, selection.Line)
+			return nil
+		}
+		day3Solver, _ := NewDay3Solver(2, nil, WithWorkers(4), WithSelectionHandler(handler))
+
+		//when
+		result, err := day3Solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Empty(t, result.PowerBanks)
+		assert.Len(t, lines, 5000)
+		for i, line := range lines {
+			assert.Equal(t, i+1, line)
+		}
+	})
+
+	t.Run("Reports every invalid power bank in input order", func(t *testing.T) {
+		t.Parallel()
+		//given
+		day3Solver, _ := NewDay3Solver(2, nil, WithWorkers(4), WithInvalidLineMode(InvalidLinesSkip))
+
+		//when
+		result, err := day3Solver.Solve(context.Background(), strings.NewReader("98\n9x\n\n12\nx"))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, "110", result.TotalJoltage.String())
+		assert.Equal(t, []int{2, 3, 5}, []int{result.InvalidPowerBanks[0].Line, result.InvalidPowerBanks[1].Line, result.InvalidPowerBanks[2].Line})
+	})
+
+	t.Run("Stops when the context is cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		for _, workers := range []int{1, 4} {
+			//given
+			day3Solver, _ := NewDay3Solver(2, nil, WithWorkers(workers))
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			//when
+			_, err := day3Solver.Solve(ctx, strings.NewReader(input))
+
+			//then
+			assert.ErrorIs(t, err, context.Canceled)
+		}
+	})
+
+	t.Run("Returns an error instead of a partial total when the context is cancelled mid-stream", func(t *testing.T) {
+		t.Parallel()
+
+		// the collector can see the closed results before the cancelled context, so it is tried a number of times
+		for range 20 {
+			//given
+			day3Solver, _ := NewDay3Solver(2, nil, WithWorkers(4))
+			ctx, cancel := context.WithCancel(context.Background())
+			reader := &cancellingReader{reader: strings.NewReader(input), cancelAfter: len(input) / 2, cancel: cancel}
+
+			//when
+			result, err := day3Solver.Solve(ctx, reader)
+
+			//then
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Nil(t, result.TotalJoltage)
+			cancel()
+		}
+	})
+
+	t.Run("Reports progress through the logger", func(t *testing.T) {
+		t.Parallel()
+		//given
+		core, logs := observer.New(zapcore.InfoLevel)
+		day3Solver, _ := NewDay3Solver(2, zap.New(core), WithWorkers(4), WithProgressInterval(1000))
+
+		//when
+		_, err := day3Solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 5, logs.FilterMessage("processed power banks").Len())
+	})
+}
+
+// cancellingReader cancels the context once more than cancelAfter bytes have been read
+type cancellingReader struct {
+	reader      *strings.Reader
+	cancelAfter int
+	cancel      context.CancelFunc
+	read        int
+}
+
+func (r *cancellingReader) Read(p []byte) (int, error) {
+	if r.read > r.cancelAfter {
+		r.cancel()
+	}
+	n, err := r.reader.Read(p)
+	r.read += n
+	return n, err
 }
@@ -0,0 +1,121 @@
+package day04
+
+import (
+	"context"
+	"sync"
+)
+
+// minCellsPerBand keeps the bands large enough that handing them to a goroutine costs less than counting them
+const minCellsPerBand = 1 << 14
+
+// bandsPerWorker gives the workers some slack so a band full of rolls does not keep every other worker waiting
+const bandsPerWorker = 4
+
+// rowBand is the rows from fromY up to but excluding toY
+type rowBand struct {
+	fromY int
+	toY   int
+}
+
+// splitIntoBands cuts the rows of the grid into consecutive bands, a single band is used when
+// there is only one worker or when the grid is too small to be worth splitting
+func splitIntoBands(grid Grid, workers int) []rowBand {
+	height := grid.Height()
+	numOfBands := 1
+	if workers > 1 && grid.Width() > 0 {
+		rowsPerBand := max(minCellsPerBand/grid.Width(), 1)
+		numOfBands = min(workers*bandsPerWorker, (height+rowsPerBand-1)/rowsPerBand)
+	}
+	numOfBands = max(min(numOfBands, height), 1)
+	bands := make([]rowBand, 0, numOfBands)
+	for i := range numOfBands {
+		bands = append(bands, rowBand{fromY: i * height / numOfBands, toY: (i + 1) * height / numOfBands})
+	}
+	return bands
+}
+
+// countNeighboursInBands counts the neighbouring rolls of every roll and returns the cell indices (y * width + x) of the ones
+// that are reachable in row major order, indices take half the memory of coordinates which adds up on large grids.
+// The rows are split into bands that are counted on separate goroutines, a band also reads the halo rows above and below it
+// that the neighbourhood reaches into, but since the grid is never written while counting the halos are shared between the
+// bands without copying or locking. When counts is not nil the count of every roll is stored in it.
+//
+// NOTE: Time complexity O(w * h * n / workers) where n is the size of the neighbourhood
+func countNeighboursInBands(ctx context.Context, grid Grid, rules rules, workers int, counts neighbourCounts) ([]int, error) {
+	bands := splitIntoBands(grid, workers)
+	// every band writes to its own slot so the results can be merged in band order no matter which band finishes first
+	reachablePerBand := make([][]int, len(bands))
+	countsPerBand := make([]neighbourCounts, len(bands))
+	errs := make([]error, len(bands))
+
+	countBand := func(i int) {
+		var bandCounts neighbourCounts
+		if counts != nil {
+			bandCounts = counts.forBand()
+		}
+		reachable := make([]int, 0)
+		row := -1
+		grid.ForEachRollInRows(bands[i].fromY, bands[i].toY, func(cell Coordinate) {
+			// the context is checked once per row, after it got cancelled the remaining rolls are skipped
+			if cell.y != row {
+				row = cell.y
+				errs[i] = ctx.Err()
+			}
+			if errs[i] != nil {
+				return
+			}
+			idx := cell.y*grid.Width() + cell.x
+			count := rules.countRollNeighbours(grid, cell)
+			if bandCounts != nil {
+				bandCounts.set(idx, count)
+			}
+			if count < rules.threshold {
+				reachable = append(reachable, idx)
+			}
+		})
+		reachablePerBand[i] = reachable
+		countsPerBand[i] = bandCounts
+	}
+
+	if len(bands) == 1 {
+		countBand(0)
+	} else {
+		queue := make(chan int, len(bands))
+		for i := range bands {
+			queue <- i
+		}
+		close(queue)
+		var wg sync.WaitGroup
+		for range min(workers, len(bands)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					countBand(i)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	numOfReachable := 0
+	for i := range bands {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		numOfReachable += len(reachablePerBand[i])
+		if counts != nil {
+			counts.merge(countsPerBand[i])
+		}
+	}
+	// a single band is handed back as it is, otherwise the bands are joined into a slice of the exact size
+	if len(bands) == 1 {
+		return reachablePerBand[0], nil
+	}
+	reachable := make([]int, 0, numOfReachable)
+	for i := range bands {
+		reachable = append(reachable, reachablePerBand[i]...)
+		reachablePerBand[i] = nil
+	}
+	return reachable, nil
+}
@@ -0,0 +1,113 @@
+package day04
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitIntoBands(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		width    int
+		height   int
+		workers  int
+		expected []rowBand
+	}{
+		{width: 1000, height: 100, workers: 1, expected: []rowBand{{fromY: 0, toY: 100}}},
+		{width: 10, height: 10, workers: 8, expected: []rowBand{{fromY: 0, toY: 10}}},
+		{width: 0, height: 0, workers: 8, expected: []rowBand{{fromY: 0, toY: 0}}},
+		{width: 16384, height: 3, workers: 8, expected: []rowBand{{fromY: 0, toY: 1}, {fromY: 1, toY: 2}, {fromY: 2, toY: 3}}},
+		{width: 8192, height: 10, workers: 2, expected: []rowBand{{fromY: 0, toY: 2}, {fromY: 2, toY: 4}, {fromY: 4, toY: 6}, {fromY: 6, toY: 8}, {fromY: 8, toY: 10}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Splits a %dx%d grid for %d workers", tt.width, tt.height, tt.workers), func(t *testing.T) {
+			t.Parallel()
+			//given
+			grid := NewBitGrid(tt.width, tt.height)
+
+			//when
+			bands := splitIntoBands(grid, tt.workers)
+
+			//then
+			assert.Equal(t, tt.expected, bands)
+		})
+	}
+}
+
+func TestCountNeighboursInBands(t *testing.T) {
+	t.Parallel()
+
+	ruleSets := map[string]rules{
+		"moore with walls":             {threshold: MinNeighborsForInaccessible, neighbourhood: MooreNeighbourhood(), edgeMode: EdgeWalls},
+		"chebyshev 3 with wrap":        {threshold: 20, neighbourhood: ChebyshevNeighbourhood(3), edgeMode: EdgeWrap},
+		"asymmetric custom with fills": {threshold: 2, neighbourhood: Neighbourhood{{DX: 1, DY: 0}, {DX: 2, DY: 9}, {DX: 0, DY: -4}}, edgeMode: EdgeFilled},
+	}
+
+	for name, rules := range ruleSets {
+		for _, representation := range []GridRepresentation{GridRepresentationDense, GridRepresentationSparse} {
+			t.Run(fmt.Sprintf("Counts the same neighbours on 8 workers as on 1 using %s and representation %d", name, representation), func(t *testing.T) {
+				t.Parallel()
+				//given
+				random := rand.New(rand.NewPCG(38, 38))
+				// an odd width makes two bands share the word of packed counters at their border
+				grid := convertGrid(newRandomGrid(random, 301, 400, 0.6), representation)
+				expectedCounts := newNeighbourCounts(grid, rules)
+				expected, _ := countNeighboursInBands(context.Background(), grid, rules, 1, expectedCounts)
+
+				//when
+				counts := newNeighbourCounts(grid, rules)
+				result, err := countNeighboursInBands(context.Background(), grid, rules, 8, counts)
+
+				//then
+				assert.NoError(t, err)
+				assert.Equal(t, expected, result)
+				assert.Equal(t, expectedCounts, counts)
+				expectedRemoved, expectedWaves, _ := removeRollsIncrementally(context.Background(), grid, rules, 1, true)
+				removed, waves, err := removeRollsIncrementally(context.Background(), grid, rules, 8, true)
+				assert.NoError(t, err)
+				assert.Equal(t, expectedRemoved, removed)
+				assert.Equal(t, expectedWaves, waves)
+			})
+		}
+	}
+
+	t.Run("Stops counting when the context is cancelled", func(t *testing.T) {
+		t.Parallel()
+		//given
+		random := rand.New(rand.NewPCG(38, 1))
+		grid := newRandomGrid(random, 300, 400, 0.6)
+		rules := rules{threshold: MinNeighborsForInaccessible, neighbourhood: MooreNeighbourhood(), edgeMode: EdgeWalls}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		//when
+		reachable, err := countNeighboursInBands(ctx, grid, rules, 4, nil)
+		_, _, removeErr := removeRollsIncrementally(ctx, grid, rules, 4, false)
+
+		//then
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, reachable)
+		assert.ErrorIs(t, removeErr, context.Canceled)
+	})
+}
+
+func BenchmarkCountNeighboursInBands(b *testing.B) {
+	random := rand.New(rand.NewPCG(38, 38))
+	grid := newRandomGrid(random, 4000, 4000, 0.6)
+	rules := rules{threshold: MinNeighborsForInaccessible, neighbourhood: MooreNeighbourhood(), edgeMode: EdgeWalls}
+
+	for _, workers := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("%d workers", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_, _ = countNeighboursInBands(context.Background(), grid, rules, workers, nil)
+			}
+		})
+	}
+}
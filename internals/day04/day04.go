@@ -3,8 +3,16 @@ package day04
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"math"
+	"math/bits"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 
 	"go.uber.org/zap"
 )
@@ -18,131 +26,668 @@ const (
 	RemovalModeRecursive
 )
 
+// EdgeMode decides what the neighbours of the cells at the edge of the grid are
+type EdgeMode int
+
+const (
+	// EdgeWalls ignores the neighbours that fall outside of the grid
+	EdgeWalls EdgeMode = iota
+	// EdgeWrap wraps the grid around so the neighbours on the opposite edge are used
+	EdgeWrap
+	// EdgeFilled treats every cell outside of the grid as a roll that can never be removed
+	EdgeFilled
+)
+
+// Offset is the relative position of a neighbour to a cell
+type Offset struct {
+	DX int
+	DY int
+}
+
+type Neighbourhood []Offset
+
+// MooreNeighbourhood is the eight cells surrounding a cell
+func MooreNeighbourhood() Neighbourhood {
+	return ChebyshevNeighbourhood(1)
+}
+
+// VonNeumannNeighbourhood is the four cells sharing an edge with a cell
+func VonNeumannNeighbourhood() Neighbourhood {
+	return ManhattanNeighbourhood(1)
+}
+
+// ChebyshevNeighbourhood is every cell within a square of the given radius around a cell
+func ChebyshevNeighbourhood(radius int) Neighbourhood {
+	neighbourhood := Neighbourhood{}
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			isTheCellItself := dx == 0 && dy == 0
+			if isTheCellItself {
+				continue
+			}
+			neighbourhood = append(neighbourhood, Offset{DX: dx, DY: dy})
+		}
+	}
+	return neighbourhood
+}
+
+// ManhattanNeighbourhood is every cell within a diamond of the given radius around a cell
+func ManhattanNeighbourhood(radius int) Neighbourhood {
+	neighbourhood := Neighbourhood{}
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			isTheCellItself := dx == 0 && dy == 0
+			if isTheCellItself || abs(dx)+abs(dy) > radius {
+				continue
+			}
+			neighbourhood = append(neighbourhood, Offset{DX: dx, DY: dy})
+		}
+	}
+	return neighbourhood
+}
+
+// ParseNeighbourhood converts a description like 'moore', 'vonneumann', 'chebyshev:2', 'manhattan:3'
+// or 'custom:1,0;-1,0;0,1' into a neighbourhood
+func ParseNeighbourhood(description string) (Neighbourhood, error) {
+	shape, argument, hasArgument := strings.Cut(description, ":")
+	switch shape {
+	case "moore":
+		return MooreNeighbourhood(), nil
+	case "vonneumann":
+		return VonNeumannNeighbourhood(), nil
+	case "chebyshev", "manhattan":
+		if !hasArgument {
+			return nil, fmt.Errorf("neighbourhood '%s' is missing the radius", description)
+		}
+		radius, err := strconv.Atoi(argument)
+		if err != nil || radius < 1 {
+			return nil, fmt.Errorf("neighbourhood '%s' should have a positive integer radius", description)
+		}
+		if shape == "chebyshev" {
+			return ChebyshevNeighbourhood(radius), nil
+		}
+		return ManhattanNeighbourhood(radius), nil
+	case "custom":
+		neighbourhood := Neighbourhood{}
+		for _, pair := range strings.Split(argument, ";") {
+			dxAsStr, dyAsStr, found := strings.Cut(pair, ",")
+			if !found {
+				return nil, fmt.Errorf("offset '%s' of neighbourhood '%s' should be in dx,dy format", pair, description)
+			}
+			dx, errX := strconv.Atoi(strings.TrimSpace(dxAsStr))
+			dy, errY := strconv.Atoi(strings.TrimSpace(dyAsStr))
+			if errX != nil || errY != nil {
+				return nil, fmt.Errorf("offset '%s' of neighbourhood '%s' should contain valid integers", pair, description)
+			}
+			neighbourhood = append(neighbourhood, Offset{DX: dx, DY: dy})
+		}
+		return neighbourhood, nil
+	default:
+		return nil, fmt.Errorf("unhandled neighbourhood '%s'", description)
+	}
+}
+
+// rules are the cellular automaton rules that decide if a forklift can reach a roll
+type rules struct {
+	threshold     int
+	neighbourhood Neighbourhood
+	edgeMode      EdgeMode
+}
+
+// Alphabet maps the characters of the input onto the cells of the grid
+type Alphabet map[rune]Cell
+
+// DefaultAlphabet is the alphabet of the puzzle input, '@' is a roll and '.' is an empty cell
+func DefaultAlphabet() Alphabet {
+	return Alphabet{'@': Roll, '.': Empty}
+}
+
+// NewAlphabet creates an alphabet where every character of rolls is a roll and every character of empties is empty
+func NewAlphabet(rolls string, empties string) (Alphabet, error) {
+	if rolls == "" || empties == "" {
+		return nil, fmt.Errorf("alphabet needs at least one roll and one empty character")
+	}
+	alphabet := Alphabet{}
+	for _, char := range rolls {
+		alphabet[char] = Roll
+	}
+	for _, char := range empties {
+		if _, ok := alphabet[char]; ok {
+			return nil, fmt.Errorf("character %q can not be both a roll and an empty cell", char)
+		}
+		alphabet[char] = Empty
+	}
+	return alphabet, nil
+}
+
+// GridParseError points at the line and the column (both counted from 1) where the grid could not be parsed
+type GridParseError struct {
+	Line   int
+	Column int
+	Reason string
+}
+
+func (e GridParseError) Error() string {
+	return fmt.Sprintf("invalid grid at line %d, column %d: %s", e.Line, e.Column, e.Reason)
+}
+
 type Day4Solver struct {
-	logger *zap.Logger
+	logger         *zap.Logger
+	rules          rules
+	reportWaves    bool
+	alphabet       Alphabet
+	representation GridRepresentation
+	workers        int
+}
+
+type Option func(*Day4Solver) error
+
+// WithThreshold makes a roll reachable when it has fewer than threshold rolls around it
+func WithThreshold(threshold int) Option {
+	return func(d *Day4Solver) error {
+		if threshold < 1 {
+			return fmt.Errorf("threshold has to be at least 1, got %d", threshold)
+		}
+		d.rules.threshold = threshold
+		return nil
+	}
+}
+
+// WithNeighbourhood sets which cells count as the neighbours of a roll
+func WithNeighbourhood(neighbourhood Neighbourhood) Option {
+	return func(d *Day4Solver) error {
+		if len(neighbourhood) == 0 {
+			return fmt.Errorf("neighbourhood can not be empty")
+		}
+		for _, offset := range neighbourhood {
+			if offset.DX == 0 && offset.DY == 0 {
+				return fmt.Errorf("neighbourhood can not contain the cell itself")
+			}
+		}
+		d.rules.neighbourhood = neighbourhood
+		return nil
+	}
+}
+
+// WithEdgeMode sets how the neighbours outside of the grid are treated
+func WithEdgeMode(edgeMode EdgeMode) Option {
+	return func(d *Day4Solver) error {
+		switch edgeMode {
+		case EdgeWalls, EdgeWrap, EdgeFilled:
+			d.rules.edgeMode = edgeMode
+		default:
+			return fmt.Errorf("unhandled edge mode %d", edgeMode)
+		}
+		return nil
+	}
+}
+
+// WithWaveReport adds every removal wave with the coordinates of the removed rolls to the solution
+func WithWaveReport() Option {
+	return func(d *Day4Solver) error {
+		d.reportWaves = true
+		return nil
+	}
+}
+
+// WithAlphabet sets which characters of the input are rolls and which ones are empty cells
+func WithAlphabet(alphabet Alphabet) Option {
+	return func(d *Day4Solver) error {
+		if len(alphabet) == 0 {
+			return fmt.Errorf("alphabet can not be empty")
+		}
+		d.alphabet = alphabet
+		return nil
+	}
+}
+
+// WithGridRepresentation forces the grid to be stored densely or sparsely instead of deciding by the density of the input
+func WithGridRepresentation(representation GridRepresentation) Option {
+	return func(d *Day4Solver) error {
+		switch representation {
+		case GridRepresentationAuto, GridRepresentationDense, GridRepresentationSparse:
+			d.representation = representation
+		default:
+			return fmt.Errorf("unhandled grid representation %d", representation)
+		}
+		return nil
+	}
+}
+
+// WithWorkers counts the neighbours of the rolls on n goroutines, each one taking a band of rows at a time
+func WithWorkers(n int) Option {
+	return func(d *Day4Solver) error {
+		if n < 1 {
+			return fmt.Errorf("number of workers has to be at least 1, got %d", n)
+		}
+		d.workers = n
+		return nil
+	}
 }
 
-func NewDay4Solver(logger *zap.Logger) (*Day4Solver, error) {
+func NewDay4Solver(logger *zap.Logger, opts ...Option) (*Day4Solver, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
 	solver := &Day4Solver{
 		logger: logger,
+		rules: rules{
+			threshold:     MinNeighborsForInaccessible,
+			neighbourhood: MooreNeighbourhood(),
+			edgeMode:      EdgeWalls,
+		},
+		reportWaves:    false,
+		alphabet:       DefaultAlphabet(),
+		representation: GridRepresentationAuto,
+		workers:        1,
+	}
+	for _, opt := range opts {
+		if err := opt(solver); err != nil {
+			return nil, err
+		}
 	}
 	return solver, nil
 }
 
-func (d *Day4Solver) Solve(ctx context.Context, reader io.Reader, removalMode RemovalMode) (int, error) {
-	grid := &Grid{
-		cells: make([][]Cell, 0),
+// RemovalWave is a set of rolls that became reachable at the same time
+type RemovalWave struct {
+	Index        int          `json:"index"`
+	RemovedCount int          `json:"removedCount"`
+	Coordinates  []Coordinate `json:"coordinates"`
+}
+
+type Solution struct {
+	RemovedRolls int `json:"removedRolls"`
+	// RemainingRolls are the rolls that are left in the grid once the forklifts are done,
+	// in recursive mode these are the ones that can never be reached
+	RemainingRolls int           `json:"remainingRolls"`
+	Waves          []RemovalWave `json:"waves,omitempty"`
+	// Grid is the warehouse as it was before any roll got removed
+	Grid Grid `json:"-"`
+}
+
+func (d *Day4Solver) Solve(ctx context.Context, reader io.Reader, removalMode RemovalMode) (Solution, error) {
+	parsed, err := parseGrid(reader, d.alphabet)
+	if err != nil {
+		return Solution{}, err
+	}
+	grid := convertGrid(parsed, d.representation)
+	d.logger.Debug("parsed grid",
+		zap.Int("width", grid.Width()),
+		zap.Int("height", grid.Height()),
+		zap.Int("rolls", grid.RollCount()),
+		zap.String("representation", fmt.Sprintf("%T", grid)),
+	)
+
+	solution := Solution{
+		RemovedRolls:   0,
+		RemainingRolls: grid.RollCount(),
+		Waves:          nil,
+		Grid:           grid,
+	}
+
+	if removalMode == RemovalModeRecursive {
+		removed, waves, err := removeRollsIncrementally(ctx, grid, d.rules, d.workers, d.reportWaves)
+		if err != nil {
+			return Solution{}, err
+		}
+		solution.RemovedRolls = removed
+		solution.Waves = waves
+	} else {
+		reachable, err := getRollsReachableViaForklift(ctx, grid, d.rules, d.workers)
+		if err != nil {
+			return Solution{}, err
+		}
+		solution.RemovedRolls = len(reachable)
+		if d.reportWaves && len(reachable) > 0 {
+			solution.Waves = []RemovalWave{newRemovalWave(0, reachable)}
+		}
 	}
+	solution.RemainingRolls -= solution.RemovedRolls
+
+	d.logger.Debug("removed rolls",
+		zap.Int("removedRolls", solution.RemovedRolls),
+		zap.Int("remainingRolls", solution.RemainingRolls),
+	)
+
+	return solution, nil
+}
+
+// maxRowBytes is the widest row that can be parsed, it leaves room for rows of millions of cells
+// even when every cell is a character of several bytes
+const maxRowBytes = 1 << 28
+
+// parseGrid rejects characters that are not in the alphabet and rows that are not as wide as the first one,
+// empty lines are only allowed at the end of the input. The rows are read straight into a bit packed grid
+// so the input never has to be held in memory as text or as one machine word per cell.
+func parseGrid(reader io.Reader, alphabet Alphabet) (*BitGrid, error) {
+	grid := NewBitGrid(0, 0)
 	scanner := bufio.NewScanner(reader)
+	// the buffer starts at the default size and only grows for rows that are wider than that
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxRowBytes)
+	lineNumber := 0
+	firstBlankLine := 0
 	for scanner.Scan() {
-		line := scanner.Text()
-		cells := strings.Split(line, "")
-		row := []Cell{}
-		for _, cell := range cells {
-			if cell == "@" {
-				row = append(row, Roll)
-			} else {
-				row = append(row, Empty)
+		// the bytes of the scanner are read in place, a row of a large grid is not worth copying into a string
+		line := scanner.Bytes()
+		lineNumber++
+		if len(line) == 0 {
+			if firstBlankLine == 0 {
+				firstBlankLine = lineNumber
+			}
+			continue
+		}
+		if firstBlankLine != 0 {
+			return nil, GridParseError{Line: firstBlankLine, Column: 1, Reason: "blank line inside the grid"}
+		}
+		if grid.Height() == 0 {
+			grid = NewBitGrid(utf8.RuneCount(line), 0)
+		}
+		grid.appendRow()
+		y := grid.Height() - 1
+		column := 0
+		for offset := 0; offset < len(line); {
+			char, size := utf8.DecodeRune(line[offset:])
+			offset += size
+			column++
+			cell, ok := alphabet[char]
+			if !ok {
+				return nil, GridParseError{Line: lineNumber, Column: column, Reason: fmt.Sprintf("unknown character %q", char)}
 			}
+			if column > grid.Width() {
+				continue
+			}
+			grid.SetCell(Coordinate{x: column - 1, y: y}, cell)
 		}
-		grid.cells = append(grid.cells, row)
+		if column != grid.Width() {
+			return nil, GridParseError{
+				Line:   lineNumber,
+				Column: min(column, grid.Width()) + 1,
+				Reason: fmt.Sprintf("row has %d cells but the first row has %d", column, grid.Width()),
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read grid: %w", err)
 	}
+	return grid, nil
+}
 
-	numOfRemovableRools := calculateNumOfRemovableRolls(grid, removalMode, 0)
+func newRemovalWave(index int, coordinates []Coordinate) RemovalWave {
+	sorted := slices.Clone(coordinates)
+	slices.SortFunc(sorted, compareCoordinates)
+	return RemovalWave{
+		Index:        index,
+		RemovedCount: len(sorted),
+		Coordinates:  sorted,
+	}
+}
 
-	return numOfRemovableRools, nil
+type Coordinate struct {
+	x int
+	y int
 }
 
-type Cell int
+func (c Coordinate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}{X: c.x, Y: c.y})
+}
 
-const (
-	Empty Cell = iota
-	Roll
-)
+func (c Coordinate) isInsideGrid(grid Grid) bool {
+	return c.x >= 0 && c.x < grid.Width() && c.y >= 0 && c.y < grid.Height()
+}
 
-type Grid struct {
-	cells [][]Cell
+func (c Coordinate) getNeighbours(neighbourhood Neighbourhood) []Coordinate {
+	neighbours := make([]Coordinate, 0, len(neighbourhood))
+	for _, offset := range neighbourhood {
+		neighbour := Coordinate{x: c.x + offset.DX, y: c.y + offset.DY}
+		neighbours = append(neighbours, neighbour)
+	}
+	return neighbours
 }
 
-func (g *Grid) clone() *Grid {
-	cloned := Grid{
-		cells: make([][]Cell, len(g.cells)),
+// resolveNeighbour maps the neighbour onto the grid, the second return value is false when the
+// neighbour is outside of the grid and the third one tells if that outside cell counts as a roll
+func (r rules) resolveNeighbour(grid Grid, neighbour Coordinate) (Coordinate, bool, bool) {
+	if neighbour.isInsideGrid(grid) {
+		return neighbour, true, false
 	}
-	for y, row := range g.cells {
-		cloned.cells[y] = make([]Cell, len(row))
-		copy(cloned.cells[y], row)
+	switch r.edgeMode {
+	case EdgeWrap:
+		width := grid.Width()
+		height := grid.Height()
+		wrapped := Coordinate{
+			x: ((neighbour.x % width) + width) % width,
+			y: ((neighbour.y % height) + height) % height,
+		}
+		return wrapped, true, false
+	case EdgeFilled:
+		return neighbour, false, true
+	default:
+		return neighbour, false, false
 	}
-	return &cloned
 }
 
-func (g *Grid) removeRolls(coordinates []Coordinate) {
-	for _, coordinate := range coordinates {
-		g.cells[coordinate.y][coordinate.x] = Empty
+// countRollNeighbours is the number of rolls in the neighbourhood of the cell, including the filled cells outside of the grid
+func (r rules) countRollNeighbours(grid Grid, cell Coordinate) int {
+	rollsNextToCell := 0
+	for _, offset := range r.neighbourhood {
+		neighbour := Coordinate{x: cell.x + offset.DX, y: cell.y + offset.DY}
+		resolved, isInside, isFilled := r.resolveNeighbour(grid, neighbour)
+		if isFilled || (isInside && grid.Cell(resolved) == Roll) {
+			rollsNextToCell += 1
+		}
 	}
+	return rollsNextToCell
 }
 
-type Coordinate struct {
-	x int
-	y int
+func getRollsReachableViaForklift(ctx context.Context, grid Grid, rules rules, workers int) ([]Coordinate, error) {
+	reachable, err := countNeighboursInBands(ctx, grid, rules, workers, nil)
+	if err != nil {
+		return nil, err
+	}
+	coordinates := make([]Coordinate, 0, len(reachable))
+	for _, idx := range reachable {
+		coordinates = append(coordinates, Coordinate{x: idx % grid.Width(), y: idx / grid.Width()})
+	}
+	return coordinates, nil
 }
 
-func (c Coordinate) isInsideGrid(grid *Grid) bool {
-	return c.x >= 0 && c.x < len(grid.cells[0]) && c.y >= 0 && c.y < len(grid.cells)
+// neighbourCounts keeps the number of neighbouring rolls of every roll while they are being removed
+type neighbourCounts interface {
+	get(idx int) int
+	set(idx int, count int)
+	// forBand returns the counts a band of rows can write to without racing the other bands
+	forBand() neighbourCounts
+	// merge takes over the counts a band wrote
+	merge(band neighbourCounts)
 }
 
-func (c Coordinate) getNeighbours() []Coordinate {
-	neighbours := make([]Coordinate, 0)
-	for y := -1; y <= 1; y++ {
-		for x := -1; x <= 1; x++ {
-			isTheCellItself := x == 0 && y == 0
-			if isTheCellItself {
-				continue
-			}
-			neighbour := Coordinate{x: c.x + x, y: c.y + y}
-			neighbours = append(neighbours, neighbour)
+// denseNeighbourCounts has a byte or a word for every cell of the grid, it is used for neighbourhoods too large to be packed
+type denseNeighbourCounts[T uint8 | int32] []T
+
+func (d denseNeighbourCounts[T]) get(idx int) int {
+	return int(d[idx])
+}
+
+func (d denseNeighbourCounts[T]) set(idx int, count int) {
+	d[idx] = T(count)
+}
+
+// forBand shares the counters, the bands never write to the same cell
+func (d denseNeighbourCounts[T]) forBand() neighbourCounts {
+	return d
+}
+
+func (d denseNeighbourCounts[T]) merge(band neighbourCounts) {}
+
+// sparseNeighbourCounts only has counters for the rolls
+type sparseNeighbourCounts map[int]int32
+
+func (s sparseNeighbourCounts) get(idx int) int {
+	return int(s[idx])
+}
+
+func (s sparseNeighbourCounts) set(idx int, count int) {
+	s[idx] = int32(count)
+}
+
+// forBand gives every band its own map as maps can not be written concurrently
+func (s sparseNeighbourCounts) forBand() neighbourCounts {
+	return make(sparseNeighbourCounts)
+}
+
+func (s sparseNeighbourCounts) merge(band neighbourCounts) {
+	for idx, count := range band.(sparseNeighbourCounts) {
+		s[idx] = count
+	}
+}
+
+// packedNeighbourCounts packs the counters of a dense grid into words with as few bits per counter as the size of the
+// neighbourhood needs, which is 4 bits for the Moore neighbourhood. Two bands share the word at the border of their rows
+// so the words are updated atomically.
+type packedNeighbourCounts struct {
+	words          []atomic.Uint32
+	bitsPerCounter int
+}
+
+func newPackedNeighbourCounts(cells int, bitsPerCounter int) *packedNeighbourCounts {
+	countersPerWord := 32 / bitsPerCounter
+	return &packedNeighbourCounts{
+		words:          make([]atomic.Uint32, (cells+countersPerWord-1)/countersPerWord),
+		bitsPerCounter: bitsPerCounter,
+	}
+}
+
+func (p *packedNeighbourCounts) locate(idx int) (int, int, uint32) {
+	countersPerWord := 32 / p.bitsPerCounter
+	return idx / countersPerWord, (idx % countersPerWord) * p.bitsPerCounter, uint32(1)<<p.bitsPerCounter - 1
+}
+
+func (p *packedNeighbourCounts) get(idx int) int {
+	word, shift, mask := p.locate(idx)
+	return int(p.words[word].Load() >> shift & mask)
+}
+
+func (p *packedNeighbourCounts) set(idx int, count int) {
+	word, shift, mask := p.locate(idx)
+	for {
+		old := p.words[word].Load()
+		updated := old&^(mask<<shift) | uint32(count)<<shift
+		if p.words[word].CompareAndSwap(old, updated) {
+			return
 		}
 	}
-	return neighbours
 }
 
-func getRollsReachableViaForklift(grid *Grid) []Coordinate {
-	coordinates := make([]Coordinate, 0)
-	for cellY, row := range grid.cells {
-		for cellX := range row {
-			cell := Coordinate{x: cellX, y: cellY}
-			if grid.cells[cell.y][cell.x] != Roll {
-				continue
+// forBand shares the counters, the bands never write to the same counter
+func (p *packedNeighbourCounts) forBand() neighbourCounts {
+	return p
+}
+
+func (p *packedNeighbourCounts) merge(band neighbourCounts) {}
+
+// maxPackedCounterBits is the widest counter that is packed, wider counters get a byte or a word of their own
+const maxPackedCounterBits = 4
+
+func newNeighbourCounts(grid Grid, rules rules) neighbourCounts {
+	if _, ok := grid.(*SparseGrid); ok {
+		return make(sparseNeighbourCounts, grid.RollCount())
+	}
+	// a roll can have every cell of the neighbourhood as a neighbour, the counter is rounded up to a power of two
+	// number of bits so a counter never straddles two words
+	if countBits := bits.Len(uint(len(rules.neighbourhood))); countBits <= maxPackedCounterBits {
+		bitsPerCounter := 1
+		for bitsPerCounter < countBits {
+			bitsPerCounter *= 2
+		}
+		return newPackedNeighbourCounts(grid.Width()*grid.Height(), bitsPerCounter)
+	}
+	if len(rules.neighbourhood) <= math.MaxUint8 {
+		return make(denseNeighbourCounts[uint8], grid.Width()*grid.Height())
+	}
+	return make(denseNeighbourCounts[int32], grid.Width()*grid.Height())
+}
+
+// removeRollsIncrementally counts the neighbours of every roll once, band by band, and then keeps a queue of rolls that became
+// reachable, removing a roll only updates the counts of its own neighbours instead of rescanning the whole grid
+//
+// NOTE: Time complexity O(w * h * n) where n is the size of the neighbourhood, as every roll enters the queue at most once
+// space complexity is O(w * h * log n) bits for the neighbour counts of a dense grid and O(r) for the r rolls of a sparse grid
+func removeRollsIncrementally(ctx context.Context, grid Grid, rules rules, workers int, recordWaves bool) (int, []RemovalWave, error) {
+	var waves []RemovalWave
+	width := grid.Width()
+	if width == 0 || grid.Height() == 0 {
+		return 0, waves, nil
+	}
+	index := func(c Coordinate) int {
+		return c.y*width + c.x
+	}
+	coordinate := func(idx int) Coordinate {
+		return Coordinate{x: idx % width, y: idx / width}
+	}
+
+	// the removals happen on a copy so the caller still has the original warehouse
+	remaining := grid.Clone()
+	counts := newNeighbourCounts(grid, rules)
+	// the first layer is used as the queue as it is, it can be a sizeable part of the rolls of a large grid
+	queue, err := countNeighboursInBands(ctx, grid, rules, workers, counts)
+	if err != nil {
+		return 0, nil, err
+	}
+	var layer []int
+
+	removed := 0
+
+	// the queue is processed one layer at a time so a roll only gets removed once every roll
+	// of the previous layer is gone, the same way the layers are peeled off by a full rescan
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+		// the two slices take turns being the layer and the queue so the layers do not allocate again and again
+		layer, queue = queue, layer[:0]
+
+		for _, idx := range layer {
+			remaining.SetCell(coordinate(idx), Empty)
+		}
+		if recordWaves {
+			coordinates := make([]Coordinate, 0, len(layer))
+			for _, idx := range layer {
+				coordinates = append(coordinates, coordinate(idx))
 			}
-			rollsNextToCell := 0
-			neighbours := cell.getNeighbours()
-			for _, neighbour := range neighbours {
-				if !neighbour.isInsideGrid(grid) {
+			waves = append(waves, newRemovalWave(len(waves), coordinates))
+		}
+		removed += len(layer)
+
+		for _, idx := range layer {
+			cell := coordinate(idx)
+			// every roll that has the removed roll as its neighbour is found by walking the neighbourhood
+			// backwards, which matters for custom neighbourhoods that are not symmetric
+			for _, offset := range rules.neighbourhood {
+				neighbour := Coordinate{x: cell.x - offset.DX, y: cell.y - offset.DY}
+				resolved, isInside, _ := rules.resolveNeighbour(grid, neighbour)
+				if !isInside || remaining.Cell(resolved) != Roll {
 					continue
 				}
-				if grid.cells[neighbour.y][neighbour.x] != Roll {
-					continue
+				count := counts.get(index(resolved)) - 1
+				counts.set(index(resolved), count)
+				// the counts only ever go down so a roll crosses the threshold exactly once
+				if count == rules.threshold-1 {
+					queue = append(queue, index(resolved))
 				}
-				rollsNextToCell += 1
-			}
-			if rollsNextToCell < MinNeighborsForInaccessible {
-				coordinates = append(coordinates, cell)
 			}
 		}
 	}
-	return coordinates
+
+	return removed, waves, nil
 }
 
-func calculateNumOfRemovableRolls(grid *Grid, removalMode RemovalMode, sum int) int {
-	rollsReachableViaForklift := getRollsReachableViaForklift(grid)
-	numOfRollsReachableViaForklift := len(rollsReachableViaForklift)
-	if numOfRollsReachableViaForklift == 0 {
-		return sum
-	}
-	sum = sum + numOfRollsReachableViaForklift
-	if removalMode == RemovalModeSingleLayer {
-		return sum
+func abs(n int) int {
+	if n < 0 {
+		return -n
 	}
-	cloned := grid.clone()
-	cloned.removeRolls(rollsReachableViaForklift)
-	return calculateNumOfRemovableRolls(cloned, removalMode, sum)
+	return n
 }
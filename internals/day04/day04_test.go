@@ -2,6 +2,9 @@ package day04
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
 	"strings"
 	"testing"
 
@@ -35,7 +38,7 @@ func TestDay4Solver_getLargestPossibleJoltage(t *testing.T) {
 
 		//then
 		assert.NoError(t, err)
-		assert.Equal(t, 13, result)
+		assert.Equal(t, 13, result.RemovedRolls)
 	})
 
 	t.Run("Correctly calculates the number of rolls where there are fewer than four rolls of paper in the eight adjacent positions and we remove reachable rolls", func(t *testing.T) {
@@ -62,6 +65,423 @@ func TestDay4Solver_getLargestPossibleJoltage(t *testing.T) {
 
 		//then
 		assert.NoError(t, err)
-		assert.Equal(t, 43, result)
+		assert.Equal(t, 43, result.RemovedRolls)
+	})
+}
+
+func TestRemoveRollsIncrementally(t *testing.T) {
+	t.Parallel()
+
+	ruleSets := map[string]rules{
+		"moore with walls":              {threshold: MinNeighborsForInaccessible, neighbourhood: MooreNeighbourhood(), edgeMode: EdgeWalls},
+		"von neumann with wrap":         {threshold: 2, neighbourhood: VonNeumannNeighbourhood(), edgeMode: EdgeWrap},
+		"chebyshev 2 with filled edges": {threshold: 12, neighbourhood: ChebyshevNeighbourhood(2), edgeMode: EdgeFilled},
+		"manhattan 2 with wrap":         {threshold: 6, neighbourhood: ManhattanNeighbourhood(2), edgeMode: EdgeWrap},
+		"asymmetric custom with walls":  {threshold: 2, neighbourhood: Neighbourhood{{DX: 1, DY: 0}, {DX: 2, DY: 1}, {DX: 0, DY: -1}}, edgeMode: EdgeWalls},
+		"wide custom with wrap":         {threshold: 2, neighbourhood: Neighbourhood{{DX: 7, DY: 0}, {DX: -1, DY: 5}, {DX: 3, DY: 3}}, edgeMode: EdgeWrap},
+	}
+
+	testCases := []struct {
+		width   int
+		height  int
+		density float64
+	}{
+		{width: 1, height: 1, density: 1},
+		{width: 3, height: 2, density: 1},
+		{width: 50, height: 3, density: 0.9},
+		{width: 200, height: 200, density: 0.5},
+		{width: 300, height: 250, density: 0.7},
+		{width: 400, height: 400, density: 0.85},
+	}
+
+	for name, rules := range ruleSets {
+		for _, tt := range testCases {
+			t.Run(fmt.Sprintf("Removes the same number of rolls as a full rescan on a %dx%d grid with density %.2f using %s", tt.width, tt.height, tt.density, name), func(t *testing.T) {
+				t.Parallel()
+				//given
+				random := rand.New(rand.NewPCG(uint64(tt.width), uint64(tt.height)))
+				grid := newRandomGrid(random, tt.width, tt.height, tt.density)
+				expected := removeLayersByRescanning(grid, rules)
+
+				//when
+				result, waves, err := removeRollsIncrementally(context.Background(), grid, rules, 1, true)
+
+				//then
+				assert.NoError(t, err)
+				assert.Equal(t, expected, result)
+				removedInWaves := 0
+				for i, wave := range waves {
+					assert.Equal(t, i, wave.Index)
+					assert.Len(t, wave.Coordinates, wave.RemovedCount)
+					removedInWaves += wave.RemovedCount
+				}
+				assert.Equal(t, expected, removedInWaves)
+			})
+		}
+	}
+}
+
+// removeLayersByRescanning is the reference for the incremental removal, it finds the reachable rolls
+// of the whole grid again after every layer that got removed
+func removeLayersByRescanning(grid Grid, rules rules) int {
+	removed := 0
+	remaining := grid.Clone()
+	for {
+		reachable, _ := getRollsReachableViaForklift(context.Background(), remaining, rules, 1)
+		if len(reachable) == 0 {
+			return removed
+		}
+		removed += len(reachable)
+		for _, cell := range reachable {
+			remaining.SetCell(cell, Empty)
+		}
+	}
+}
+
+func newRandomGrid(random *rand.Rand, width int, height int, density float64) *BitGrid {
+	grid := NewBitGrid(width, height)
+	for y := range height {
+		for x := range width {
+			if random.Float64() < density {
+				grid.SetCell(Coordinate{x: x, y: y}, Roll)
+			}
+		}
+	}
+	return grid
+}
+
+func TestDay4Solver_rules(t *testing.T) {
+	t.Parallel()
+
+	input := `@@@
+@@@
+@@@`
+
+	testCases := []struct {
+		name     string
+		opts     []Option
+		expected int
+	}{
+		{name: "moore neighbourhood with walls only reaches the corners", opts: nil, expected: 4},
+		{name: "von neumann neighbourhood with walls reaches the corners", opts: []Option{WithNeighbourhood(VonNeumannNeighbourhood()), WithThreshold(3)}, expected: 4},
+		{name: "von neumann neighbourhood with walls reaches the edges with a higher threshold", opts: []Option{WithNeighbourhood(VonNeumannNeighbourhood()), WithThreshold(4)}, expected: 8},
+		{name: "wrapping edges make every roll surrounded", opts: []Option{WithEdgeMode(EdgeWrap)}, expected: 0},
+		{name: "filled edges make every roll surrounded", opts: []Option{WithEdgeMode(EdgeFilled)}, expected: 0},
+		{name: "filled edges with a high threshold reach everything", opts: []Option{WithEdgeMode(EdgeFilled), WithThreshold(9)}, expected: 9},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, errSolver := NewDay4Solver(nil, tt.opts...)
+
+			//when
+			result, err := solver.Solve(context.Background(), strings.NewReader(input), RemovalModeSingleLayer)
+
+			//then
+			assert.NoError(t, errSolver)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result.RemovedRolls)
+		})
+	}
+
+	t.Run("Rejects a neighbourhood containing the cell itself", func(t *testing.T) {
+		t.Parallel()
+		//when
+		_, err := NewDay4Solver(nil, WithNeighbourhood(Neighbourhood{{DX: 0, DY: 0}}))
+
+		//then
+		assert.Error(t, err)
+	})
+}
+
+func TestParseNeighbourhood(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description string
+		expected    Neighbourhood
+	}{
+		{description: "moore", expected: MooreNeighbourhood()},
+		{description: "vonneumann", expected: Neighbourhood{{DX: 0, DY: -1}, {DX: -1, DY: 0}, {DX: 1, DY: 0}, {DX: 0, DY: 1}}},
+		{description: "chebyshev:2", expected: ChebyshevNeighbourhood(2)},
+		{description: "manhattan:2", expected: ManhattanNeighbourhood(2)},
+		{description: "custom:1,0;-2,3", expected: Neighbourhood{{DX: 1, DY: 0}, {DX: -2, DY: 3}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Parses neighbourhood %s", tt.description), func(t *testing.T) {
+			t.Parallel()
+			//when
+			result, err := ParseNeighbourhood(tt.description)
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	for _, description := range []string{"hexagonal", "chebyshev", "manhattan:0", "custom:1", "custom:a,b"} {
+		t.Run(fmt.Sprintf("Rejects neighbourhood %s", description), func(t *testing.T) {
+			t.Parallel()
+			//when
+			_, err := ParseNeighbourhood(description)
+
+			//then
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("Builds neighbourhoods of the expected size", func(t *testing.T) {
+		t.Parallel()
+		assert.Len(t, MooreNeighbourhood(), 8)
+		assert.Len(t, VonNeumannNeighbourhood(), 4)
+		assert.Len(t, ChebyshevNeighbourhood(2), 24)
+		assert.Len(t, ManhattanNeighbourhood(2), 12)
+	})
+}
+
+func TestDay4Solver_waveReport(t *testing.T) {
+	t.Parallel()
+
+	input := `..@@.@@@@.
+@@@.@.@.@@
+@@@@@.@.@@
+@.@@@@..@.
+@@.@@@@.@@
+.@@@@@@@.@
+.@.@.@.@@@
+@.@@@.@@@@
+.@@@@@@@@.
+@.@.@@@.@.`
+
+	t.Run("Reports every removal wave and the rolls that can never be removed", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay4Solver(nil, WithWaveReport())
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(input), RemovalModeRecursive)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 43, result.RemovedRolls)
+		assert.Equal(t, 28, result.RemainingRolls)
+		removedCounts := []int{}
+		for _, wave := range result.Waves {
+			removedCounts = append(removedCounts, wave.RemovedCount)
+		}
+		assert.Equal(t, []int{13, 12, 7, 5, 2, 1, 1, 1, 1}, removedCounts)
+		assert.Equal(t, Coordinate{x: 2, y: 0}, result.Waves[0].Coordinates[0])
+	})
+
+	t.Run("Reports a single wave in single layer mode", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay4Solver(nil, WithWaveReport())
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(input), RemovalModeSingleLayer)
+
+		//then
+		assert.NoError(t, err)
+		assert.Len(t, result.Waves, 1)
+		assert.Equal(t, 13, result.Waves[0].RemovedCount)
+		assert.Equal(t, 58, result.RemainingRolls)
+	})
+
+	t.Run("Leaves the waves out when they were not asked for", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay4Solver(nil)
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(input), RemovalModeRecursive)
+
+		//then
+		assert.NoError(t, err)
+		assert.Nil(t, result.Waves)
+		assert.Equal(t, 28, result.RemainingRolls)
+	})
+
+	t.Run("Encodes the report as JSON", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solution := Solution{
+			RemovedRolls:   2,
+			RemainingRolls: 1,
+			Waves:          []RemovalWave{{Index: 0, RemovedCount: 2, Coordinates: []Coordinate{{x: 1, y: 0}, {x: 0, y: 2}}}},
+		}
+
+		//when
+		result, err := json.Marshal(solution)
+
+		//then
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"removedRolls":2,"remainingRolls":1,"waves":[{"index":0,"removedCount":2,"coordinates":[{"x":1,"y":0},{"x":0,"y":2}]}]}`, string(result))
+	})
+}
+
+func TestDay4Solver_parsing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rejects invalid grids with the line and column of the problem", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := []struct {
+			name     string
+			input    string
+			expected GridParseError
+		}{
+			{name: "unknown character", input: "..@\n.x@", expected: GridParseError{Line: 2, Column: 2, Reason: "unknown character 'x'"}},
+			{name: "carriage return inside a row", input: "..@\n.\r@", expected: GridParseError{Line: 2, Column: 2, Reason: "unknown character '\\r'"}},
+			{name: "shorter row", input: "..@\n.@\n@@@", expected: GridParseError{Line: 2, Column: 3, Reason: "row has 2 cells but the first row has 3"}},
+			{name: "longer row", input: "..@\n.@@@", expected: GridParseError{Line: 2, Column: 4, Reason: "row has 4 cells but the first row has 3"}},
+			{name: "blank line inside the grid", input: "..@\n\n.@@", expected: GridParseError{Line: 2, Column: 1, Reason: "blank line inside the grid"}},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+				//given
+				solver, _ := NewDay4Solver(nil)
+
+				//when
+				_, err := solver.Solve(context.Background(), strings.NewReader(tt.input), RemovalModeRecursive)
+
+				//then
+				var parseErr GridParseError
+				assert.ErrorAs(t, err, &parseErr)
+				assert.Equal(t, tt.expected, parseErr)
+			})
+		}
+	})
+
+	t.Run("Solves an empty input without any removals", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"", "\n\n"} {
+			for _, removalMode := range []RemovalMode{RemovalModeSingleLayer, RemovalModeRecursive} {
+				//given
+				solver, _ := NewDay4Solver(nil, WithEdgeMode(EdgeWrap))
+
+				//when
+				result, err := solver.Solve(context.Background(), strings.NewReader(input), removalMode)
+
+				//then
+				assert.NoError(t, err)
+				assert.Equal(t, 0, result.RemovedRolls)
+				assert.Equal(t, 0, result.RemainingRolls)
+			}
+		}
+	})
+
+	t.Run("Allows blank lines at the end of the input", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay4Solver(nil)
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader("@@\n@.\n\n"), RemovalModeSingleLayer)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result.RemovedRolls)
+	})
+
+	t.Run("Reads the grid with a custom alphabet", func(t *testing.T) {
+		t.Parallel()
+		//given
+		alphabet, errAlphabet := NewAlphabet("#O", " .")
+		solver, _ := NewDay4Solver(nil, WithAlphabet(alphabet))
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader("#O#\n. #"), RemovalModeSingleLayer)
+
+		//then
+		assert.NoError(t, errAlphabet)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, result.RemovedRolls)
+	})
+
+	t.Run("Reads rows wider than the default buffer of a scanner", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay4Solver(nil)
+		row := strings.Repeat("@.", 35000)
+		input := strings.Join([]string{row, row, row}, "\n")
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(input), RemovalModeSingleLayer)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 70000, result.Grid.Width())
+		assert.Equal(t, 3*35000, result.RemovedRolls)
+	})
+
+	t.Run("Rejects an alphabet where a character is both a roll and empty", func(t *testing.T) {
+		t.Parallel()
+		//when
+		_, err := NewAlphabet("@.", ".")
+
+		//then
+		assert.Error(t, err)
+	})
+}
+
+func TestNeighbourCounts(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		neighbourhood Neighbourhood
+		expected      neighbourCounts
+	}{
+		{neighbourhood: Neighbourhood{{DX: 1, DY: 0}}, expected: newPackedNeighbourCounts(301*7, 1)},
+		{neighbourhood: Neighbourhood{{DX: 1, DY: 0}, {DX: -1, DY: 0}, {DX: 0, DY: 1}}, expected: newPackedNeighbourCounts(301*7, 2)},
+		{neighbourhood: VonNeumannNeighbourhood(), expected: newPackedNeighbourCounts(301*7, 4)},
+		{neighbourhood: MooreNeighbourhood(), expected: newPackedNeighbourCounts(301*7, 4)},
+		{neighbourhood: ChebyshevNeighbourhood(3), expected: make(denseNeighbourCounts[uint8], 301*7)},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Keeps the counts of a neighbourhood of %d cells in %T", len(tt.neighbourhood), tt.expected), func(t *testing.T) {
+			t.Parallel()
+			//given
+			grid := NewBitGrid(301, 7)
+			random := rand.New(rand.NewPCG(uint64(len(tt.neighbourhood)), 37))
+			expected := make([]int, 301*7)
+			counts := newNeighbourCounts(grid, rules{threshold: 1, neighbourhood: tt.neighbourhood, edgeMode: EdgeWalls})
+
+			//when
+			for idx := range expected {
+				expected[idx] = random.IntN(len(tt.neighbourhood) + 1)
+				counts.set(idx, expected[idx])
+			}
+			for idx := range expected {
+				if idx%3 == 0 {
+					expected[idx] = random.IntN(len(tt.neighbourhood) + 1)
+					counts.set(idx, expected[idx])
+				}
+			}
+
+			//then
+			assert.IsType(t, tt.expected, counts)
+			for idx := range expected {
+				assert.Equal(t, expected[idx], counts.get(idx), "counter %d", idx)
+			}
+		})
+	}
+
+	t.Run("Packs the counters of the Moore neighbourhood into half a byte per cell", func(t *testing.T) {
+		t.Parallel()
+		//given
+		grid := NewBitGrid(1000, 1000)
+
+		//when
+		counts := newNeighbourCounts(grid, rules{threshold: MinNeighborsForInaccessible, neighbourhood: MooreNeighbourhood(), edgeMode: EdgeWalls})
+
+		//then
+		assert.Len(t, counts.(*packedNeighbourCounts).words, 1000*1000/8)
 	})
 }
@@ -0,0 +1,243 @@
+package day04
+
+import (
+	"cmp"
+	"math/bits"
+	"slices"
+)
+
+type Cell int
+
+const (
+	Empty Cell = iota
+	Roll
+)
+
+type GridRepresentation int
+
+const (
+	// GridRepresentationAuto picks the representation that needs less memory for the density of the input
+	GridRepresentationAuto GridRepresentation = iota
+	GridRepresentationDense
+	GridRepresentationSparse
+)
+
+// sparseDensityThreshold is the ratio of rolls to cells below which a sparse grid takes less memory,
+// a dense grid spends a single bit on every cell while a sparse grid spends a few hundred bits on every roll
+const sparseDensityThreshold = 1.0 / 512
+
+// Grid is the map of the warehouse, cells outside of the grid are always empty
+type Grid interface {
+	Width() int
+	Height() int
+	Cell(c Coordinate) Cell
+	SetCell(c Coordinate, cell Cell)
+	// RollCount is the number of rolls currently on the grid
+	RollCount() int
+	// ForEachRoll visits the rolls row by row starting from the top left corner
+	ForEachRoll(visit func(c Coordinate))
+	// ForEachRollInRows visits the rolls of the rows from fromY up to but excluding toY in the same order as ForEachRoll
+	ForEachRollInRows(fromY int, toY int, visit func(c Coordinate))
+	Clone() Grid
+}
+
+// convertGrid copies the rolls of the grid into the requested representation,
+// the grid is returned as it is when it already has the right representation
+func convertGrid(grid Grid, representation GridRepresentation) Grid {
+	if representation == GridRepresentationAuto {
+		cells := grid.Width() * grid.Height()
+		representation = GridRepresentationDense
+		if cells > 0 && float64(grid.RollCount())/float64(cells) < sparseDensityThreshold {
+			representation = GridRepresentationSparse
+		}
+	}
+	var converted Grid
+	switch representation {
+	case GridRepresentationSparse:
+		if _, ok := grid.(*SparseGrid); ok {
+			return grid
+		}
+		converted = NewSparseGrid(grid.Width(), grid.Height())
+	default:
+		if _, ok := grid.(*BitGrid); ok {
+			return grid
+		}
+		converted = NewBitGrid(grid.Width(), grid.Height())
+	}
+	grid.ForEachRoll(func(c Coordinate) {
+		converted.SetCell(c, Roll)
+	})
+	return converted
+}
+
+// BitGrid is a dense grid that stores every cell as a single bit, every row starts on a new word
+type BitGrid struct {
+	width       int
+	height      int
+	wordsPerRow int
+	words       []uint64
+	rollCount   int
+}
+
+func NewBitGrid(width int, height int) *BitGrid {
+	wordsPerRow := (width + 63) / 64
+	return &BitGrid{
+		width:       width,
+		height:      height,
+		wordsPerRow: wordsPerRow,
+		words:       make([]uint64, wordsPerRow*height),
+		rollCount:   0,
+	}
+}
+
+func (g *BitGrid) Width() int {
+	return g.width
+}
+
+func (g *BitGrid) Height() int {
+	return g.height
+}
+
+func (g *BitGrid) Cell(c Coordinate) Cell {
+	if !c.isInsideGrid(g) {
+		return Empty
+	}
+	word, mask := g.position(c)
+	if g.words[word]&mask != 0 {
+		return Roll
+	}
+	return Empty
+}
+
+func (g *BitGrid) SetCell(c Coordinate, cell Cell) {
+	if !c.isInsideGrid(g) {
+		return
+	}
+	word, mask := g.position(c)
+	wasRoll := g.words[word]&mask != 0
+	switch {
+	case cell == Roll && !wasRoll:
+		g.words[word] |= mask
+		g.rollCount++
+	case cell != Roll && wasRoll:
+		g.words[word] &^= mask
+		g.rollCount--
+	}
+}
+
+func (g *BitGrid) RollCount() int {
+	return g.rollCount
+}
+
+func (g *BitGrid) ForEachRoll(visit func(c Coordinate)) {
+	g.ForEachRollInRows(0, g.height, visit)
+}
+
+func (g *BitGrid) ForEachRollInRows(fromY int, toY int, visit func(c Coordinate)) {
+	for y := max(fromY, 0); y < min(toY, g.height); y++ {
+		for w := 0; w < g.wordsPerRow; w++ {
+			word := g.words[y*g.wordsPerRow+w]
+			// jump straight to the set bits so empty stretches of the grid cost a single check per 64 cells
+			for word != 0 {
+				bit := bits.TrailingZeros64(word)
+				visit(Coordinate{x: w*64 + bit, y: y})
+				word &= word - 1
+			}
+		}
+	}
+}
+
+func (g *BitGrid) Clone() Grid {
+	return &BitGrid{
+		width:       g.width,
+		height:      g.height,
+		wordsPerRow: g.wordsPerRow,
+		words:       slices.Clone(g.words),
+		rollCount:   g.rollCount,
+	}
+}
+
+// appendRow grows the grid by an empty row at the bottom
+func (g *BitGrid) appendRow() {
+	g.words = append(g.words, make([]uint64, g.wordsPerRow)...)
+	g.height++
+}
+
+func (g *BitGrid) position(c Coordinate) (int, uint64) {
+	return c.y*g.wordsPerRow + c.x/64, 1 << (c.x % 64)
+}
+
+// SparseGrid only stores the coordinates of the rolls, which is cheaper than a dense grid for mostly empty warehouses
+type SparseGrid struct {
+	width  int
+	height int
+	rolls  map[Coordinate]struct{}
+}
+
+func NewSparseGrid(width int, height int) *SparseGrid {
+	return &SparseGrid{
+		width:  width,
+		height: height,
+		rolls:  make(map[Coordinate]struct{}),
+	}
+}
+
+func (g *SparseGrid) Width() int {
+	return g.width
+}
+
+func (g *SparseGrid) Height() int {
+	return g.height
+}
+
+func (g *SparseGrid) Cell(c Coordinate) Cell {
+	if _, ok := g.rolls[c]; ok {
+		return Roll
+	}
+	return Empty
+}
+
+func (g *SparseGrid) SetCell(c Coordinate, cell Cell) {
+	if !c.isInsideGrid(g) {
+		return
+	}
+	if cell == Roll {
+		g.rolls[c] = struct{}{}
+		return
+	}
+	delete(g.rolls, c)
+}
+
+func (g *SparseGrid) RollCount() int {
+	return len(g.rolls)
+}
+
+func (g *SparseGrid) ForEachRoll(visit func(c Coordinate)) {
+	g.ForEachRollInRows(0, g.height, visit)
+}
+
+func (g *SparseGrid) ForEachRollInRows(fromY int, toY int, visit func(c Coordinate)) {
+	coordinates := make([]Coordinate, 0)
+	for coordinate := range g.rolls {
+		if coordinate.y >= fromY && coordinate.y < toY {
+			coordinates = append(coordinates, coordinate)
+		}
+	}
+	slices.SortFunc(coordinates, compareCoordinates)
+	for _, coordinate := range coordinates {
+		visit(coordinate)
+	}
+}
+
+func (g *SparseGrid) Clone() Grid {
+	cloned := NewSparseGrid(g.width, g.height)
+	for coordinate := range g.rolls {
+		cloned.rolls[coordinate] = struct{}{}
+	}
+	return cloned
+}
+
+// compareCoordinates orders the coordinates row by row
+func compareCoordinates(a Coordinate, b Coordinate) int {
+	return cmp.Or(cmp.Compare(a.y, b.y), cmp.Compare(a.x, b.x))
+}
@@ -0,0 +1,195 @@
+package day04
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrid(t *testing.T) {
+	t.Parallel()
+
+	constructors := map[string]func(width int, height int) Grid{
+		"bit grid":    func(width int, height int) Grid { return NewBitGrid(width, height) },
+		"sparse grid": func(width int, height int) Grid { return NewSparseGrid(width, height) },
+	}
+
+	for name, newGrid := range constructors {
+		t.Run(fmt.Sprintf("Sets and clears cells on a %s", name), func(t *testing.T) {
+			t.Parallel()
+			//given
+			grid := newGrid(130, 3)
+
+			//when
+			grid.SetCell(Coordinate{x: 129, y: 2}, Roll)
+			grid.SetCell(Coordinate{x: 64, y: 0}, Roll)
+			grid.SetCell(Coordinate{x: 0, y: 1}, Roll)
+			grid.SetCell(Coordinate{x: 0, y: 1}, Roll)
+			grid.SetCell(Coordinate{x: 63, y: 1}, Roll)
+			grid.SetCell(Coordinate{x: 63, y: 1}, Empty)
+			grid.SetCell(Coordinate{x: 130, y: 0}, Roll)
+			grid.SetCell(Coordinate{x: -1, y: 0}, Roll)
+
+			//then
+			assert.Equal(t, 130, grid.Width())
+			assert.Equal(t, 3, grid.Height())
+			assert.Equal(t, 3, grid.RollCount())
+			assert.Equal(t, Roll, grid.Cell(Coordinate{x: 64, y: 0}))
+			assert.Equal(t, Empty, grid.Cell(Coordinate{x: 63, y: 1}))
+			assert.Equal(t, Empty, grid.Cell(Coordinate{x: 130, y: 0}))
+			visited := []Coordinate{}
+			grid.ForEachRoll(func(c Coordinate) {
+				visited = append(visited, c)
+			})
+			assert.Equal(t, []Coordinate{{x: 64, y: 0}, {x: 0, y: 1}, {x: 129, y: 2}}, visited)
+		})
+
+		t.Run(fmt.Sprintf("Clones a %s without sharing cells", name), func(t *testing.T) {
+			t.Parallel()
+			//given
+			grid := newGrid(2, 2)
+			grid.SetCell(Coordinate{x: 1, y: 1}, Roll)
+
+			//when
+			cloned := grid.Clone()
+			cloned.SetCell(Coordinate{x: 1, y: 1}, Empty)
+			cloned.SetCell(Coordinate{x: 0, y: 0}, Roll)
+
+			//then
+			assert.Equal(t, Roll, grid.Cell(Coordinate{x: 1, y: 1}))
+			assert.Equal(t, Empty, grid.Cell(Coordinate{x: 0, y: 0}))
+			assert.Equal(t, 1, grid.RollCount())
+			assert.Equal(t, 1, cloned.RollCount())
+		})
+	}
+
+	t.Run("Picks the representation by the density of the grid", func(t *testing.T) {
+		t.Parallel()
+		//given
+		sparse := NewBitGrid(1000, 1000)
+		sparse.SetCell(Coordinate{x: 5, y: 5}, Roll)
+		dense := NewBitGrid(10, 10)
+		dense.SetCell(Coordinate{x: 5, y: 5}, Roll)
+
+		//when
+		convertedSparse := convertGrid(sparse, GridRepresentationAuto)
+		convertedDense := convertGrid(dense, GridRepresentationAuto)
+		forcedSparse := convertGrid(dense, GridRepresentationSparse)
+
+		//then
+		assert.IsType(t, &SparseGrid{}, convertedSparse)
+		assert.Equal(t, Roll, convertedSparse.Cell(Coordinate{x: 5, y: 5}))
+		assert.IsType(t, &BitGrid{}, convertedDense)
+		assert.IsType(t, &SparseGrid{}, forcedSparse)
+		assert.Equal(t, 1, forcedSparse.RollCount())
+	})
+
+	t.Run("Solves the same way with every representation", func(t *testing.T) {
+		t.Parallel()
+		//given
+		random := rand.New(rand.NewPCG(37, 37))
+		var builder strings.Builder
+		for range 120 {
+			for range 150 {
+				if random.Float64() < 0.6 {
+					builder.WriteByte('@')
+				} else {
+					builder.WriteByte('.')
+				}
+			}
+			builder.WriteByte('\n')
+		}
+		input := builder.String()
+		results := []Solution{}
+
+		//when
+		for _, representation := range []GridRepresentation{GridRepresentationDense, GridRepresentationSparse} {
+			solver, _ := NewDay4Solver(nil, WithGridRepresentation(representation), WithWaveReport(), WithEdgeMode(EdgeWrap))
+			result, err := solver.Solve(context.Background(), strings.NewReader(input), RemovalModeRecursive)
+			assert.NoError(t, err)
+			results = append(results, result)
+		}
+
+		//then
+		assert.Equal(t, results[0].RemovedRolls, results[1].RemovedRolls)
+		assert.Equal(t, results[0].RemainingRolls, results[1].RemainingRolls)
+		assert.Equal(t, results[0].Waves, results[1].Waves)
+	})
+}
+
+func BenchmarkRemoveRollsIncrementally(b *testing.B) {
+	benchmarks := []struct {
+		size    int
+		density float64
+	}{
+		{size: 1000, density: 0.6},
+		{size: 1000, density: 0.01},
+		{size: 4000, density: 0.6},
+		{size: 4000, density: 0.001},
+		{size: 10000, density: 0.0005},
+	}
+
+	rules := rules{threshold: MinNeighborsForInaccessible, neighbourhood: MooreNeighbourhood(), edgeMode: EdgeWalls}
+
+	for _, bm := range benchmarks {
+		random := rand.New(rand.NewPCG(uint64(bm.size), 4))
+		dense := newRandomGrid(random, bm.size, bm.size, bm.density)
+		grids := map[string]Grid{
+			"dense":  dense,
+			"sparse": convertGrid(dense, GridRepresentationSparse),
+		}
+		for _, name := range []string{"dense", "sparse"} {
+			b.Run(fmt.Sprintf("%s %dx%d density %.4f", name, bm.size, bm.size, bm.density), func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					removeRollsIncrementally(context.Background(), grids[name], rules, 1, false)
+				}
+				// the metric is reported after the loop as starting the loop resets the reported metrics
+				b.ReportMetric(float64(measurePeakHeap(func() {
+					removeRollsIncrementally(context.Background(), grids[name], rules, 1, false)
+				})), "peak-heap-B")
+			})
+		}
+	}
+}
+
+// measurePeakHeap runs the function once and samples the heap while it runs, the peak is how much the heap grew
+// above what was live before the function started, so it counts the memory held at once and not the total allocated
+func measurePeakHeap(run func()) uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	baseline := stats.HeapAlloc
+	peak := baseline
+
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				var sample runtime.MemStats
+				runtime.ReadMemStats(&sample)
+				peak = max(peak, sample.HeapAlloc)
+			}
+		}
+	}()
+
+	run()
+	close(done)
+	<-sampled
+	// what the function left behind is only collected later, so it was still held when the function returned
+	runtime.ReadMemStats(&stats)
+	return max(peak, stats.HeapAlloc) - baseline
+}
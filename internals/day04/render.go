@@ -0,0 +1,163 @@
+package day04
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"strings"
+)
+
+const (
+	rollSymbol  = '@'
+	emptySymbol = '.'
+	// waveSymbols colour-code the waves when the frames are rendered without ANSI colours
+	waveSymbols = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// waveANSIColours are cycled through when there are more waves than colours
+var waveANSIColours = []int{31, 32, 33, 34, 35, 36, 91, 92, 93, 94, 95, 96}
+
+// maxWaveGIFColours keeps the palette within the 256 colours a GIF frame can have
+const maxWaveGIFColours = 250
+
+type GIFOptions struct {
+	// CellSize is the width and height of a single cell in pixels
+	CellSize int
+	// Delay is the time between frames in 100ths of a second
+	Delay int
+}
+
+// waveIndexes maps every removed roll to the wave it was removed in
+func waveIndexes(waves []RemovalWave) map[Coordinate]int {
+	removedInWave := make(map[Coordinate]int)
+	for _, wave := range waves {
+		for _, coordinate := range wave.Coordinates {
+			removedInWave[coordinate] = wave.Index
+		}
+	}
+	return removedInWave
+}
+
+// RenderFrames draws the grid before any removal and then after every wave, the removed rolls keep the
+// colour of their wave in every later frame and the rolls removed by the latest wave are highlighted.
+// Without colours the waves are told apart by the symbol they are drawn with.
+func RenderFrames(grid Grid, waves []RemovalWave, withColours bool) []string {
+	removedInWave := waveIndexes(waves)
+	frames := make([]string, 0, len(waves)+1)
+	for frame := 0; frame <= len(waves); frame++ {
+		var builder strings.Builder
+		for y := 0; y < grid.Height(); y++ {
+			for x := 0; x < grid.Width(); x++ {
+				coordinate := Coordinate{x: x, y: y}
+				wave, isRemoved := removedInWave[coordinate]
+				switch {
+				case grid.Cell(coordinate) != Roll:
+					builder.WriteByte(emptySymbol)
+				case !isRemoved || wave >= frame:
+					builder.WriteByte(rollSymbol)
+				case !withColours:
+					builder.WriteByte(waveSymbols[wave%len(waveSymbols)])
+				case wave == frame-1:
+					// reverse video makes the rolls of the latest wave stand out from the earlier ones
+					fmt.Fprintf(&builder, "\033[7;%dm%c\033[0m", waveANSIColours[wave%len(waveANSIColours)], rollSymbol)
+				default:
+					fmt.Fprintf(&builder, "\033[%dm%c\033[0m", waveANSIColours[wave%len(waveANSIColours)], rollSymbol)
+				}
+			}
+			builder.WriteByte('\n')
+		}
+		frames = append(frames, builder.String())
+	}
+	return frames
+}
+
+// RenderGIF writes an animated GIF of the same frames as RenderFrames, the rolls removed by the latest wave fill
+// their whole cell while the rolls of earlier waves are drawn as smaller squares in their wave colour
+func RenderGIF(w io.Writer, grid Grid, waves []RemovalWave, opts GIFOptions) error {
+	if grid.Width() == 0 || grid.Height() == 0 {
+		return fmt.Errorf("can not render an empty grid")
+	}
+	if opts.CellSize < 1 {
+		return fmt.Errorf("cell size has to be at least 1 pixel, got %d", opts.CellSize)
+	}
+
+	const (
+		emptyColourIdx = iota
+		rollColourIdx
+		firstWaveColourIdx
+	)
+	waveColours := min(max(len(waves), 1), maxWaveGIFColours)
+	palette := color.Palette{
+		color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff},
+		color.RGBA{R: 0x5a, G: 0x3e, B: 0x2b, A: 0xff},
+	}
+	for i := range waveColours {
+		palette = append(palette, hueToColour(float64(i)/float64(waveColours)))
+	}
+
+	width := grid.Width() * opts.CellSize
+	height := grid.Height() * opts.CellSize
+	inset := opts.CellSize / 4
+	removedInWave := waveIndexes(waves)
+
+	animation := &gif.GIF{}
+	for frame := 0; frame <= len(waves); frame++ {
+		img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		for y := 0; y < grid.Height(); y++ {
+			for x := 0; x < grid.Width(); x++ {
+				coordinate := Coordinate{x: x, y: y}
+				if grid.Cell(coordinate) != Roll {
+					continue
+				}
+				cellRect := image.Rect(x*opts.CellSize, y*opts.CellSize, (x+1)*opts.CellSize, (y+1)*opts.CellSize)
+				wave, isRemoved := removedInWave[coordinate]
+				if !isRemoved || wave >= frame {
+					fillRect(img, cellRect, rollColourIdx)
+					continue
+				}
+				colourIdx := uint8(firstWaveColourIdx + wave%waveColours)
+				if wave == frame-1 {
+					fillRect(img, cellRect, colourIdx)
+					continue
+				}
+				fillRect(img, cellRect.Inset(inset), colourIdx)
+			}
+		}
+		animation.Image = append(animation.Image, img)
+		animation.Delay = append(animation.Delay, opts.Delay)
+	}
+
+	return gif.EncodeAll(w, animation)
+}
+
+func fillRect(img *image.Paletted, rect image.Rectangle, colourIdx uint8) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetColorIndex(x, y, colourIdx)
+		}
+	}
+}
+
+// hueToColour turns a hue between 0 and 1 into a fully saturated colour
+func hueToColour(hue float64) color.RGBA {
+	sector := hue * 6
+	fraction := sector - float64(int(sector))
+	rising := uint8(fraction * 0xff)
+	falling := 0xff - rising
+	switch int(sector) % 6 {
+	case 0:
+		return color.RGBA{R: 0xff, G: rising, B: 0, A: 0xff}
+	case 1:
+		return color.RGBA{R: falling, G: 0xff, B: 0, A: 0xff}
+	case 2:
+		return color.RGBA{R: 0, G: 0xff, B: rising, A: 0xff}
+	case 3:
+		return color.RGBA{R: 0, G: falling, B: 0xff, A: 0xff}
+	case 4:
+		return color.RGBA{R: rising, G: 0, B: 0xff, A: 0xff}
+	default:
+		return color.RGBA{R: 0xff, G: 0, B: falling, A: 0xff}
+	}
+}
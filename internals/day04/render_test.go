@@ -0,0 +1,81 @@
+package day04
+
+import (
+	"bytes"
+	"context"
+	"image/gif"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderFrames(t *testing.T) {
+	t.Parallel()
+
+	grid, _ := parseGrid(strings.NewReader(".@@\n@@@"), DefaultAlphabet())
+	waves := []RemovalWave{
+		{Index: 0, RemovedCount: 2, Coordinates: []Coordinate{{x: 2, y: 0}, {x: 0, y: 1}}},
+		{Index: 1, RemovedCount: 1, Coordinates: []Coordinate{{x: 1, y: 0}}},
+	}
+
+	t.Run("Draws a frame before the first wave and after every wave", func(t *testing.T) {
+		t.Parallel()
+		//when
+		frames := RenderFrames(grid, waves, false)
+
+		//then
+		assert.Equal(t, []string{
+			".@@\n@@@\n",
+			".@0\n0@@\n",
+			".10\n0@@\n",
+		}, frames)
+	})
+
+	t.Run("Colour-codes the waves and highlights the latest one", func(t *testing.T) {
+		t.Parallel()
+		//when
+		frames := RenderFrames(grid, waves, true)
+
+		//then
+		assert.Len(t, frames, 3)
+		assert.Equal(t, ".@\033[7;31m@\033[0m\n\033[7;31m@\033[0m@@\n", frames[1])
+		assert.Equal(t, ".\033[7;32m@\033[0m\033[31m@\033[0m\n\033[31m@\033[0m@@\n", frames[2])
+	})
+}
+
+func TestRenderGIF(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Encodes one image per frame", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay4Solver(nil, WithWaveReport())
+		solution, _ := solver.Solve(context.Background(), strings.NewReader(".@@\n@@@\n@@."), RemovalModeRecursive)
+		var buffer bytes.Buffer
+
+		//when
+		err := RenderGIF(&buffer, solution.Grid, solution.Waves, GIFOptions{CellSize: 4, Delay: 10})
+
+		//then
+		assert.NoError(t, err)
+		decoded, errDecode := gif.DecodeAll(&buffer)
+		assert.NoError(t, errDecode)
+		assert.Len(t, decoded.Image, len(solution.Waves)+1)
+		assert.Equal(t, 12, decoded.Image[0].Bounds().Dx())
+		assert.Equal(t, 12, decoded.Image[0].Bounds().Dy())
+		assert.Equal(t, 10, decoded.Delay[0])
+	})
+
+	t.Run("Refuses to render an empty grid", func(t *testing.T) {
+		t.Parallel()
+		//given
+		grid := NewBitGrid(0, 0)
+
+		//when
+		err := RenderGIF(&bytes.Buffer{}, grid, nil, GIFOptions{CellSize: 4})
+
+		//then
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,150 @@
+package day05
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/GabrielDCelery/advent-of-code-2025/internals/intervals"
+	"go.uber.org/zap"
+)
+
+// RangeDiff is the ingredient IDs covered by only one of two range sections, as merged ranges
+type RangeDiff struct {
+	OnlyInFirst       []IngredientRange `json:"onlyInFirst"`
+	OnlyInFirstCount  *big.Int          `json:"onlyInFirstCount"`
+	OnlyInSecond      []IngredientRange `json:"onlyInSecond"`
+	OnlyInSecondCount *big.Int          `json:"onlyInSecondCount"`
+}
+
+// OverlapDepth is the number of ingredient IDs that are covered by exactly Depth ranges
+type OverlapDepth struct {
+	Depth int      `json:"depth"`
+	IDs   *big.Int `json:"ids"`
+}
+
+type RangeStatistics struct {
+	RawRanges    int `json:"rawRanges"`
+	MergedRanges int `json:"mergedRanges"`
+	// Coverage is the number of ingredient IDs covered by at least one range
+	Coverage *big.Int `json:"coverage"`
+	// LargestGap is the widest stretch of IDs between two ranges that no range covers, nil when the ranges leave no gap
+	LargestGap     *IngredientRange `json:"largestGap"`
+	LargestGapSize uint64           `json:"largestGapSize"`
+	// OverlapDepths has an entry for every depth from 1 up to the deepest overlap that covers any ID, in ascending order
+	OverlapDepths []OverlapDepth `json:"overlapDepths"`
+}
+
+// DiffRanges reads the range sections of two inputs and finds the IDs that only one of them covers
+func (d *Day5Solver) DiffRanges(ctx context.Context, first io.Reader, second io.Reader) (RangeDiff, error) {
+	firstSources, _, _, err := readRangeSection(ctx, first)
+	if err != nil {
+		return RangeDiff{}, err
+	}
+	secondSources, _, _, err := readRangeSection(ctx, second)
+	if err != nil {
+		return RangeDiff{}, err
+	}
+
+	firstSet := intervals.NewSet(mergeSources(firstSources, true)...)
+	secondSet := intervals.NewSet(mergeSources(secondSources, true)...)
+	onlyInFirst := IngredientRanges(firstSet.Difference(secondSet).Intervals())
+	onlyInSecond := IngredientRanges(secondSet.Difference(firstSet).Intervals())
+
+	d.logger.Debug("diffed ranges",
+		zap.Int("numOfFirstRanges", len(firstSources)),
+		zap.Int("numOfSecondRanges", len(secondSources)),
+	)
+
+	return RangeDiff{
+		OnlyInFirst:       onlyInFirst,
+		OnlyInFirstCount:  onlyInFirst.countAvailableIngredients(),
+		OnlyInSecond:      onlyInSecond,
+		OnlyInSecondCount: onlyInSecond.countAvailableIngredients(),
+	}, nil
+}
+
+// RangeStats reads the range section of the input and describes how the ranges cover the ingredient IDs
+func (d *Day5Solver) RangeStats(ctx context.Context, reader io.Reader) (RangeStatistics, error) {
+	sources, _, _, err := readRangeSection(ctx, reader)
+	if err != nil {
+		return RangeStatistics{}, err
+	}
+
+	merged := mergeSources(sources, d.coalesceAdjacent)
+	stats := RangeStatistics{
+		RawRanges:      len(sources),
+		MergedRanges:   len(merged),
+		Coverage:       merged.countAvailableIngredients(),
+		LargestGap:     nil,
+		LargestGapSize: 0,
+		OverlapDepths:  countOverlapDepths(sources),
+	}
+
+	// the gaps have to be looked for between coalesced ranges, two ranges that only touch leave no gap
+	coalesced := merged
+	if !d.coalesceAdjacent {
+		coalesced = mergeSources(sources, true)
+	}
+	for i := 1; i < len(coalesced); i++ {
+		gap := IngredientRange{Min: coalesced[i-1].Max + 1, Max: coalesced[i].Min - 1}
+		size, _ := gap.Cardinality()
+		if size > stats.LargestGapSize {
+			stats.LargestGap = &gap
+			stats.LargestGapSize = size
+		}
+	}
+
+	return stats, nil
+}
+
+func mergeSources(sources []SourceRange, coalesceAdjacent bool) IngredientRanges {
+	ranges := make(IngredientRanges, 0, len(sources))
+	for _, source := range sources {
+		ranges.addRange(source.IngredientRange)
+	}
+	ranges.merge(coalesceAdjacent)
+	return ranges
+}
+
+// countOverlapDepths adds up how many IDs are covered by each number of open ranges during the sweep
+//
+// NOTE: Time complexity O(n log n)
+func countOverlapDepths(sources []SourceRange) []OverlapDepth {
+	idsPerDepth := make(map[int]*big.Int)
+	addIDs := func(depth int, ids *big.Int) {
+		if _, ok := idsPerDepth[depth]; !ok {
+			idsPerDepth[depth] = new(big.Int)
+		}
+		idsPerDepth[depth].Add(idsPerDepth[depth], ids)
+	}
+
+	sweepRanges(sources, func(open map[int]struct{}, position IngredientID, next IngredientID, hasNext bool) {
+		depth := len(open)
+		if depth == 0 {
+			return
+		}
+		if hasNext {
+			addIDs(depth, new(big.Int).SetUint64(next-position))
+			return
+		}
+		// the ranges that are still open reach up to the largest ID, which can be 2^64 IDs in total
+		ids := new(big.Int).SetUint64(math.MaxUint64 - position)
+		addIDs(depth, ids.Add(ids, big.NewInt(1)))
+	})
+
+	maxDepth := 0
+	for depth := range idsPerDepth {
+		maxDepth = max(maxDepth, depth)
+	}
+	overlapDepths := make([]OverlapDepth, 0, maxDepth)
+	for depth := 1; depth <= maxDepth; depth++ {
+		ids, ok := idsPerDepth[depth]
+		if !ok {
+			ids = new(big.Int)
+		}
+		overlapDepths = append(overlapDepths, OverlapDepth{Depth: depth, IDs: ids})
+	}
+	return overlapDepths
+}
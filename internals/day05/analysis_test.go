@@ -0,0 +1,136 @@
+package day05
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDay5Solver_DiffRanges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Finds the IDs that only one of the range sections covers", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+		first := "3-5\n10-14\n\n4"
+		second := "4-6\n12-16\n15-20\n"
+
+		//when
+		diff, err := solver.DiffRanges(context.Background(), strings.NewReader(first), strings.NewReader(second))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, []IngredientRange{{Min: 3, Max: 3}, {Min: 10, Max: 11}}, diff.OnlyInFirst)
+		assert.Equal(t, big.NewInt(3), diff.OnlyInFirstCount)
+		assert.Equal(t, []IngredientRange{{Min: 6, Max: 6}, {Min: 15, Max: 20}}, diff.OnlyInSecond)
+		assert.Equal(t, big.NewInt(7), diff.OnlyInSecondCount)
+	})
+
+	t.Run("Finds no difference between the same IDs written differently", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+
+		//when
+		diff, err := solver.DiffRanges(context.Background(), strings.NewReader("1-3\n4-6"), strings.NewReader("2-6\n1-1"))
+
+		//then
+		assert.NoError(t, err)
+		assert.Empty(t, diff.OnlyInFirst)
+		assert.Equal(t, big.NewInt(0), diff.OnlyInFirstCount)
+		assert.Empty(t, diff.OnlyInSecond)
+		assert.Equal(t, big.NewInt(0), diff.OnlyInSecondCount)
+	})
+
+	t.Run("Reports the line of an invalid range of the second input", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+
+		//when
+		_, err := solver.DiffRanges(context.Background(), strings.NewReader("1-3"), strings.NewReader("1-3\n1-x"))
+
+		//then
+		assert.Equal(t, InputParseError{Line: 2, Reason: "ingredient range '1-x' should contain valid integers"}, err)
+	})
+}
+
+func TestDay5Solver_RangeStats(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Describes how the ranges cover the IDs", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+		input := "3-5\n10-14\n16-20\n12-18\n6-6\n\n1\n5"
+
+		//when
+		stats, err := solver.RangeStats(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, RangeStatistics{
+			RawRanges:      5,
+			MergedRanges:   3,
+			Coverage:       big.NewInt(15),
+			LargestGap:     &IngredientRange{Min: 7, Max: 9},
+			LargestGapSize: 3,
+			OverlapDepths: []OverlapDepth{
+				{Depth: 1, IDs: big.NewInt(9)},
+				{Depth: 2, IDs: big.NewInt(6)},
+			},
+		}, stats)
+	})
+
+	t.Run("Counts the merged ranges with adjacent ranges coalesced", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil, WithAdjacentCoalescing())
+
+		//when
+		stats, err := solver.RangeStats(context.Background(), strings.NewReader("3-5\n6-6\n8-9"))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stats.MergedRanges)
+		assert.Equal(t, &IngredientRange{Min: 7, Max: 7}, stats.LargestGap)
+	})
+
+	t.Run("Counts overlaps that cover every ID", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+		input := "0-18446744073709551615\n0-18446744073709551615\n5-5"
+
+		//when
+		stats, err := solver.RangeStats(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		everyID, _ := new(big.Int).SetString("18446744073709551616", 10)
+		assert.Equal(t, everyID, stats.Coverage)
+		assert.Nil(t, stats.LargestGap)
+		assert.Equal(t, []OverlapDepth{
+			{Depth: 1, IDs: big.NewInt(0)},
+			{Depth: 2, IDs: new(big.Int).Sub(everyID, big.NewInt(1))},
+			{Depth: 3, IDs: big.NewInt(1)},
+		}, stats.OverlapDepths)
+	})
+
+	t.Run("Describes an empty range section", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+
+		//when
+		stats, err := solver.RangeStats(context.Background(), strings.NewReader(""))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, RangeStatistics{Coverage: big.NewInt(0), OverlapDepths: []OverlapDepth{}}, stats)
+	})
+}
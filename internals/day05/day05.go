@@ -2,13 +2,18 @@ package day05
 
 import (
 	"bufio"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"sort"
+	"math"
+	"math/big"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/GabrielDCelery/advent-of-code-2025/internals/intervals"
 	"go.uber.org/zap"
 )
 
@@ -19,20 +24,109 @@ const (
 	ReadingIngredients
 )
 
+// LookupStrategy decides how an ingredient is matched against the ingredient ranges
+type LookupStrategy int
+
+const (
+	// LookupBinarySearch searches the sorted and merged ranges
+	LookupBinarySearch LookupStrategy = iota
+	// LookupIntervalTree keeps the ranges in an interval tree as they are read, without having to merge them first
+	LookupIntervalTree
+)
+
 type Day5Solver struct {
-	logger *zap.Logger
+	logger                *zap.Logger
+	lookup                LookupStrategy
+	coalesceAdjacent      bool
+	explanationHandler    ExplanationHandler
+	reportRedundantRanges bool
+}
+
+// ExplanationHandler receives the explanation of every ingredient in input order
+type ExplanationHandler func(explanation IngredientExplanation) error
+
+type Option func(*Day5Solver) error
+
+// WithLookupStrategy sets how the freshness of the ingredients is checked
+func WithLookupStrategy(lookup LookupStrategy) Option {
+	return func(d *Day5Solver) error {
+		switch lookup {
+		case LookupBinarySearch, LookupIntervalTree:
+			d.lookup = lookup
+		default:
+			return fmt.Errorf("unhandled lookup strategy %d", lookup)
+		}
+		return nil
+	}
 }
 
-func NewDay5Solver(logger *zap.Logger) (*Day5Solver, error) {
+// WithAdjacentCoalescing also merges the ranges that do not overlap but follow each other without a gap
+func WithAdjacentCoalescing() Option {
+	return func(d *Day5Solver) error {
+		d.coalesceAdjacent = true
+		return nil
+	}
+}
+
+// WithExplanationHandler explains to the handler which ranges make every ingredient fresh
+func WithExplanationHandler(handler ExplanationHandler) Option {
+	return func(d *Day5Solver) error {
+		d.explanationHandler = handler
+		return nil
+	}
+}
+
+// WithRedundantRangeReport adds the ranges that are fully covered by other ranges to the solution
+func WithRedundantRangeReport() Option {
+	return func(d *Day5Solver) error {
+		d.reportRedundantRanges = true
+		return nil
+	}
+}
+
+func NewDay5Solver(logger *zap.Logger, opts ...Option) (*Day5Solver, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
 	solver := &Day5Solver{
-		logger: logger,
+		logger:                logger,
+		lookup:                LookupBinarySearch,
+		coalesceAdjacent:      false,
+		explanationHandler:    nil,
+		reportRedundantRanges: false,
+	}
+	for _, opt := range opts {
+		if err := opt(solver); err != nil {
+			return nil, err
+		}
 	}
 	return solver, nil
 }
 
 type Solution struct {
-	FreshIngredientsCount     int
-	AvailableIngredientsCount int
+	FreshIngredientsCount int
+	// AvailableIngredientsCount can reach 2^64 when the ranges cover every ingredient ID, which does not fit into an uint64
+	AvailableIngredientsCount *big.Int
+	// RedundantRanges are the ranges that could be left out without making any ingredient spoiled,
+	// when the same range is listed twice both of them are redundant
+	RedundantRanges []SourceRange
+}
+
+// SourceRange is an ingredient range as it was written on the given line of the input
+type SourceRange struct {
+	Line int `json:"line"`
+	IngredientRange
+}
+
+// IngredientExplanation tells why an ingredient is fresh or spoiled
+type IngredientExplanation struct {
+	Line       int          `json:"line"`
+	Ingredient IngredientID `json:"ingredient"`
+	IsFresh    bool         `json:"fresh"`
+	// Ranges are the ranges of the input that contain the ingredient in the order they were written
+	Ranges []SourceRange `json:"ranges"`
+	// MergedRange is the range the ingredient fell into after the overlapping ranges got merged
+	MergedRange *IngredientRange `json:"mergedRange"`
 }
 
 func (d *Day5Solver) Solve(ctx context.Context, reader io.Reader) (Solution, error) {
@@ -41,99 +135,439 @@ func (d *Day5Solver) Solve(ctx context.Context, reader io.Reader) (Solution, err
 	scanner := bufio.NewScanner(reader)
 
 	ingredientRanges := IngredientRanges{}
+	isMerged := false
+
+	// the ranges are merged once before the first lookup instead of when the blank line is seen,
+	// so an input without the blank line or without any ingredient still gets its ranges merged
+	ensureMerged := func() {
+		if isMerged {
+			return
+		}
+		ingredientRanges.merge(d.coalesceAdjacent)
+		isMerged = true
+		d.logger.Debug("merged ingredient ranges", zap.Int("numOfRanges", len(ingredientRanges)))
+	}
+
+	// explaining an ingredient needs every range that contains it and not just the merged one, which is what the tree can find
+	var tree *intervalTree
+	if d.lookup == LookupIntervalTree || d.explanationHandler != nil {
+		tree = &intervalTree{}
+	}
+
+	sources := make([]SourceRange, 0)
 
 	solution := Solution{
-		FreshIngredientsCount:     0, // count of ingredients with valid ranges
-		AvailableIngredientsCount: 0, // total count of all unique ingredients in all the ranges
+		FreshIngredientsCount:     0,   // count of ingredients with valid ranges
+		AvailableIngredientsCount: nil, // total count of all unique ingredients in all the ranges
 	}
 
+	lineNumber := 0
 	for scanner.Scan() {
+		lineNumber++
 		line := scanner.Text()
 		if line == "" {
 			readMode = ReadingIngredients
-			ingredientRanges.merge()
-			d.logger.Debug("merged ingredient ranges", zap.String("ranges", fmt.Sprintf("%+v", ingredientRanges)))
 			continue
 		}
+		// a line without a range separator can only be an ingredient, which also ends the ranges when the blank line is missing
+		if readMode == ReadingRanges && !strings.Contains(line, "-") {
+			readMode = ReadingIngredients
+		}
 		switch readMode {
 		case ReadingRanges:
-			boundaries := strings.Split(line, "-")
-			if len(boundaries) != 2 {
-				return Solution{}, fmt.Errorf("ingredient range '%s' should have min max boundaries", line)
-			}
-			min, err := strconv.Atoi(boundaries[0])
+			rang, err := parseIngredientRange(line)
 			if err != nil {
-				return Solution{}, fmt.Errorf("ingredient range '%s' should contain valid integers", line)
+				return Solution{}, InputParseError{Line: lineNumber, Reason: err.Error()}
 			}
-			max, err := strconv.Atoi(boundaries[1])
-			if err != nil {
-				return Solution{}, fmt.Errorf("ingredient range '%s' should contain valid integers", line)
+			ingredientRanges.addRange(rang)
+			source := SourceRange{Line: lineNumber, IngredientRange: rang}
+			if tree != nil {
+				tree.insert(source)
+			}
+			if d.reportRedundantRanges {
+				sources = append(sources, source)
 			}
-			ingredientRanges.addRange(IngredientRange{min, max})
 		case ReadingIngredients:
-			ingredient, err := strconv.Atoi(line)
+			ingredient, err := parseIngredientID(line)
 			if err != nil {
-				return Solution{}, fmt.Errorf("ingredient '%s' should be a valid integer", line)
+				return Solution{}, InputParseError{Line: lineNumber, Reason: err.Error()}
+			}
+			isFresh := false
+			if d.explanationHandler != nil {
+				ensureMerged()
+				explanation := explainIngredient(lineNumber, ingredient, tree, ingredientRanges)
+				if err := d.explanationHandler(explanation); err != nil {
+					return Solution{}, err
+				}
+				isFresh = explanation.IsFresh
+			} else if tree != nil {
+				isFresh = tree.contains(ingredient)
+			} else {
+				ensureMerged()
+				isFresh = ingredientRanges.isFreshIngredient(ingredient)
 			}
-			if ingredientRanges.isFreshIngredient(ingredient) {
+			if isFresh {
 				solution.FreshIngredientsCount += 1
 			}
 		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		return Solution{}, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	ensureMerged()
 	solution.AvailableIngredientsCount = ingredientRanges.countAvailableIngredients()
 
+	if d.reportRedundantRanges {
+		solution.RedundantRanges = findRedundantRanges(sources)
+	}
+
 	return solution, nil
 }
 
-// IngredientRange represents a closed interval [min,max] of ingredient ID
-type IngredientRange struct {
-	min int
-	max int
+func explainIngredient(line int, ingredient IngredientID, tree *intervalTree, merged IngredientRanges) IngredientExplanation {
+	explanation := IngredientExplanation{
+		Line:        line,
+		Ingredient:  ingredient,
+		IsFresh:     false,
+		Ranges:      make([]SourceRange, 0),
+		MergedRange: nil,
+	}
+	tree.findAll(ingredient, func(source SourceRange) {
+		explanation.Ranges = append(explanation.Ranges, source)
+	})
+	slices.SortFunc(explanation.Ranges, func(a SourceRange, b SourceRange) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+	if idx, found := intervals.SearchSorted(merged, ingredient); found {
+		explanation.IsFresh = true
+		explanation.MergedRange = &merged[idx]
+	}
+	return explanation
 }
 
-type IngredientRanges []IngredientRange
+// sweepRanges walks over the boundaries of the ranges in order keeping track of which ranges are open. Once
+// every range starting or ending at a position is handled, visit is called with the ranges that are open
+// from that position up to next, the position of the following boundary. After the last boundary hasNext
+// is false and the ranges that are still open reach up to the largest ID.
+//
+// NOTE: Time complexity O(n log n)
+func sweepRanges(sources []SourceRange, visit func(open map[int]struct{}, position IngredientID, next IngredientID, hasNext bool)) {
+	type boundary struct {
+		position IngredientID
+		idx      int
+		isStart  bool
+	}
+	boundaries := make([]boundary, 0, 2*len(sources))
+	for idx, source := range sources {
+		boundaries = append(boundaries, boundary{position: source.Min, idx: idx, isStart: true})
+		// a range ending at the largest integer is never closed, it stays open until the end of the sweep
+		if end, ok := source.End(); ok {
+			boundaries = append(boundaries, boundary{position: end, idx: idx, isStart: false})
+		}
+	}
+	slices.SortFunc(boundaries, func(a boundary, b boundary) int {
+		return cmp.Compare(a.position, b.position)
+	})
 
-func (ir *IngredientRanges) addRange(rang IngredientRange) {
-	*ir = append(*ir, rang)
+	open := make(map[int]struct{})
+	for i := 0; i < len(boundaries); {
+		position := boundaries[i].position
+		for ; i < len(boundaries) && boundaries[i].position == position; i++ {
+			if boundaries[i].isStart {
+				open[boundaries[i].idx] = struct{}{}
+			} else {
+				delete(open, boundaries[i].idx)
+			}
+		}
+		if i < len(boundaries) {
+			visit(open, position, boundaries[i].position, true)
+		} else {
+			visit(open, position, 0, false)
+		}
+	}
 }
 
-func (ir *IngredientRanges) merge() {
-	if len(*ir) == 1 {
-		return
-	}
-	ranges := *ir
-	sort.Slice(ranges, func(i int, j int) bool {
-		return ranges[i].min < ranges[j].min
+// findRedundantRanges marks a range as needed as soon as it is the only open one anywhere during the sweep,
+// the ranges that are never needed are redundant
+//
+// NOTE: Time complexity O(n log n)
+func findRedundantRanges(sources []SourceRange) []SourceRange {
+	isNeeded := make([]bool, len(sources))
+	sweepRanges(sources, func(open map[int]struct{}, _ IngredientID, _ IngredientID, _ bool) {
+		// the ingredients from this position up to the next boundary are only covered by the single open range
+		if len(open) == 1 {
+			for idx := range open {
+				isNeeded[idx] = true
+			}
+		}
 	})
-	merged := make(IngredientRanges, 0)
-	current := ranges[0]
-	for i := 1; i < len(*ir); i++ {
-		// merge overlapping ranges (e.g. [1-5] and [3-7] becomes [1-7])
-		if ranges[i].min <= current.max {
-			current.max = max(current.max, ranges[i].max)
-		} else {
-			merged = append(merged, current)
-			current = ranges[i]
+
+	redundant := make([]SourceRange, 0)
+	for idx, source := range sources {
+		if !isNeeded[idx] {
+			redundant = append(redundant, source)
 		}
 	}
-	merged = append(merged, current)
-	*ir = merged
+	return redundant
 }
 
-func (ir *IngredientRanges) isFreshIngredient(ingredient int) bool {
-	for _, rang := range *ir {
-		if ingredient >= rang.min && ingredient <= rang.max {
-			return true
+// Inventory is the ingredient ranges loaded once so the freshness of ingredients can be queried one at a time
+type Inventory struct {
+	ranges IngredientRanges
+	tree   *intervalTree
+}
+
+func (i *Inventory) isFreshIngredient(ingredient IngredientID) bool {
+	if i.tree != nil {
+		return i.tree.contains(ingredient)
+	}
+	return i.ranges.isFreshIngredient(ingredient)
+}
+
+// LoadInventory reads the range section of the input up to the first line that is blank or that is not a range.
+// The rest of the input is returned together with the inventory, it starts with the line that is not a range
+// and has whatever got buffered while reading the ranges, so queries on the same stream have to be served from it.
+// The number of lines consumed from the input is returned as well so the queries can be numbered after them.
+func (d *Day5Solver) LoadInventory(ctx context.Context, reader io.Reader) (*Inventory, io.Reader, int, error) {
+	sources, rest, consumedLines, err := readRangeSection(ctx, reader)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	inventory := &Inventory{
+		ranges: make(IngredientRanges, 0, len(sources)),
+		tree:   nil,
+	}
+	if d.lookup == LookupIntervalTree {
+		inventory.tree = &intervalTree{}
+	}
+	for _, source := range sources {
+		inventory.ranges.addRange(source.IngredientRange)
+		if inventory.tree != nil {
+			inventory.tree.insert(source)
 		}
 	}
-	return false
+
+	inventory.ranges.merge(d.coalesceAdjacent)
+	d.logger.Debug("loaded inventory", zap.Int("numOfRanges", len(sources)), zap.Int("numOfMergedRanges", len(inventory.ranges)))
+	return inventory, rest, consumedLines, nil
 }
 
-func (ir *IngredientRanges) countAvailableIngredients() int {
-	numOfAvailableIngredients := 0
+// readRangeSection reads the ranges up to the first line that is blank or that is not a range. The lines are read
+// one at a time instead of with a scanner so nothing after the section is lost, the blank line is consumed with
+// the ranges while a line that is not a range is put back in front of the returned rest of the input.
+func readRangeSection(ctx context.Context, reader io.Reader) ([]SourceRange, io.Reader, int, error) {
+	buffered := bufio.NewReader(reader)
+	sources := make([]SourceRange, 0)
+	consumedLines := 0
+
+	for lineNumber := 1; ; lineNumber++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, 0, err
+		}
+		rawLine, readErr := buffered.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return nil, nil, 0, fmt.Errorf("failed to read ranges: %w", readErr)
+		}
+		line := strings.TrimSuffix(strings.TrimSuffix(rawLine, "\n"), "\r")
+		if line == "" {
+			// reaching the end of the input right after the last newline is not a line of its own
+			if rawLine != "" {
+				consumedLines = lineNumber
+			}
+			break
+		}
+		if !strings.Contains(line, "-") {
+			return sources, io.MultiReader(strings.NewReader(rawLine), buffered), consumedLines, nil
+		}
+		rang, err := parseIngredientRange(line)
+		if err != nil {
+			return nil, nil, 0, InputParseError{Line: lineNumber, Reason: err.Error()}
+		}
+		sources = append(sources, SourceRange{Line: lineNumber, IngredientRange: rang})
+		consumedLines = lineNumber
+		if readErr != nil {
+			break
+		}
+	}
+
+	return sources, buffered, consumedLines, nil
+}
+
+// QueryAnswer is the answer to a single freshness query, together with the counts of every query answered so far
+type QueryAnswer struct {
+	Line       int          `json:"line"`
+	Ingredient IngredientID `json:"ingredient"`
+	IsFresh    bool         `json:"fresh"`
+	Error      string       `json:"error,omitempty"`
+	FreshCount int          `json:"freshCount"`
+	QueryCount int          `json:"queryCount"`
+}
+
+// AnswerHandler receives the answer to every query as soon as the query is read
+type AnswerHandler func(answer QueryAnswer) error
+
+// ServeQueries answers the freshness queries of the reader one line at a time until the reader is exhausted or
+// the context is done. An invalid query is answered with an error instead of stopping the stream, blank lines are skipped.
+// The lines of the reader are numbered after the linesBefore lines that preceded it, so when the queries follow the
+// ranges on the same stream the line of an answer counts from the start of that stream.
+// The reader is read on its own goroutine so a cancelled context is noticed even while waiting for the next query,
+// in that case the goroutine is only gone once the pending read returns.
+func (d *Day5Solver) ServeQueries(ctx context.Context, inventory *Inventory, reader io.Reader, linesBefore int, handler AnswerHandler) (QueryAnswer, error) {
+	type scannedLine struct {
+		text string
+		err  error
+	}
+	lines := make(chan scannedLine)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			select {
+			case lines <- scannedLine{text: scanner.Text()}:
+			case <-done:
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			select {
+			case lines <- scannedLine{err: err}:
+			case <-done:
+			}
+		}
+	}()
+
+	last := QueryAnswer{}
+	lineNumber := linesBefore
+	for {
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				return last, nil
+			}
+			if line.err != nil {
+				return last, fmt.Errorf("failed to read queries: %w", line.err)
+			}
+			lineNumber++
+			text := strings.TrimSpace(line.text)
+			if text == "" {
+				continue
+			}
+			answer := QueryAnswer{
+				Line:       lineNumber,
+				Ingredient: 0,
+				IsFresh:    false,
+				Error:      "",
+				FreshCount: last.FreshCount,
+				QueryCount: last.QueryCount + 1,
+			}
+			ingredient, err := parseIngredientID(text)
+			if err != nil {
+				answer.Error = err.Error()
+			} else {
+				answer.Ingredient = ingredient
+				answer.IsFresh = inventory.isFreshIngredient(ingredient)
+			}
+			if answer.IsFresh {
+				answer.FreshCount += 1
+			}
+			if err := handler(answer); err != nil {
+				return answer, err
+			}
+			last = answer
+		}
+	}
+}
+
+// InputParseError points at the line (counted from 1) of the input that could not be parsed
+type InputParseError struct {
+	Line   int
+	Reason string
+}
+
+func (e InputParseError) Error() string {
+	return fmt.Sprintf("invalid input at line %d: %s", e.Line, e.Reason)
+}
+
+func parseIngredientRange(line string) (IngredientRange, error) {
+	minText, maxText, found := strings.Cut(line, "-")
+	if !found {
+		return IngredientRange{}, fmt.Errorf("ingredient range '%s' should have min max boundaries", line)
+	}
+	if minText == "" || strings.HasPrefix(maxText, "-") {
+		return IngredientRange{}, fmt.Errorf("ingredient range '%s' can not have negative boundaries", line)
+	}
+	min, minErr := strconv.ParseUint(minText, 10, 64)
+	max, maxErr := strconv.ParseUint(maxText, 10, 64)
+	if errors.Is(minErr, strconv.ErrRange) || errors.Is(maxErr, strconv.ErrRange) {
+		return IngredientRange{}, fmt.Errorf("ingredient range '%s' goes beyond the largest ingredient ID %d", line, uint64(math.MaxUint64))
+	}
+	if minErr != nil || maxErr != nil {
+		return IngredientRange{}, fmt.Errorf("ingredient range '%s' should contain valid integers", line)
+	}
+	if min > max {
+		return IngredientRange{}, fmt.Errorf("ingredient range '%s' has its min above its max", line)
+	}
+	return IngredientRange{Min: min, Max: max}, nil
+}
+
+// IngredientID is unsigned and 64 bits wide, the IDs of the puzzle input already have 15 digits
+type IngredientID = uint64
+
+// IngredientRange represents a closed interval [min,max] of ingredient ID
+type IngredientRange = intervals.Interval[IngredientID]
+
+type IngredientRanges []IngredientRange
+
+func (ir *IngredientRanges) addRange(rang IngredientRange) {
+	*ir = append(*ir, rang)
+}
+
+// merge sorts the ranges and joins the overlapping ones, with coalesceAdjacent ranges that
+// only touch (e.g. [1-3] and [4-6]) are joined as well
+func (ir *IngredientRanges) merge(coalesceAdjacent bool) {
+	*ir = intervals.Merge(*ir, coalesceAdjacent)
+}
+
+// isFreshIngredient expects the ranges to be merged, so they are sorted by both their min and their max
+//
+// NOTE: Time complexity O(log n)
+func (ir *IngredientRanges) isFreshIngredient(ingredient IngredientID) bool {
+	return intervals.ContainsSorted(*ir, ingredient)
+}
+
+// countAvailableIngredients expects the ranges to be merged so no ingredient is counted twice. The count is
+// summed as an uint64 and only summed again as a big.Int in the rare case where the uint64 overflows,
+// which needs the ranges to cover every single ingredient ID.
+func (ir *IngredientRanges) countAvailableIngredients() *big.Int {
+	if numOfAvailableIngredients, ok := intervals.Cardinality(*ir); ok {
+		return new(big.Int).SetUint64(numOfAvailableIngredients)
+	}
+	numOfAvailableIngredients := new(big.Int)
+	one := big.NewInt(1)
 	for _, rang := range *ir {
-		numOfAvailableIngredients += (rang.max - rang.min + 1)
+		// max - min + 1 can overflow on its own, so the range is counted as a big.Int as well
+		count := new(big.Int).SetUint64(rang.Max - rang.Min)
+		numOfAvailableIngredients.Add(numOfAvailableIngredients, count.Add(count, one))
 	}
 	return numOfAvailableIngredients
 }
+
+// parseIngredientID parses an ingredient query
+func parseIngredientID(text string) (IngredientID, error) {
+	ingredient, err := strconv.ParseUint(text, 10, 64)
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		return 0, fmt.Errorf("ingredient '%s' is above the largest ingredient ID %d", text, uint64(math.MaxUint64))
+	case err != nil:
+		return 0, fmt.Errorf("ingredient '%s' should be a valid integer", text)
+	}
+	return ingredient, nil
+}
@@ -2,6 +2,13 @@ package day05
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand/v2"
 	"strings"
 	"testing"
 
@@ -58,6 +65,444 @@ func TestDay5Solver(t *testing.T) {
 
 		//then
 		assert.NoError(t, err)
-		assert.Equal(t, 14, result.AvailableIngredientsCount)
+		assert.Equal(t, big.NewInt(14), result.AvailableIngredientsCount)
 	})
 }
+
+func TestDay5Solver_lookupStrategies(t *testing.T) {
+	t.Parallel()
+
+	strategies := map[string]LookupStrategy{
+		"binary search": LookupBinarySearch,
+		"interval tree": LookupIntervalTree,
+	}
+
+	for name, strategy := range strategies {
+		t.Run(fmt.Sprintf("Counts the same fresh ingredients as a linear scan using %s", name), func(t *testing.T) {
+			t.Parallel()
+			//given
+			random := rand.New(rand.NewPCG(39, 39))
+			ranges, ingredients := newRandomInput(random, 500, 5000, 10000)
+			input := formatInput(ranges, ingredients)
+			expected := 0
+			for _, ingredient := range ingredients {
+				for _, rang := range ranges {
+					if rang.Min <= ingredient && ingredient <= rang.Max {
+						expected += 1
+						break
+					}
+				}
+			}
+			solver, _ := NewDay5Solver(nil, WithLookupStrategy(strategy))
+
+			//when
+			result, err := solver.Solve(context.Background(), strings.NewReader(input))
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, expected, result.FreshIngredientsCount)
+		})
+	}
+
+	t.Run("Rejects unknown lookup strategies", func(t *testing.T) {
+		t.Parallel()
+		//when
+		_, err := NewDay5Solver(nil, WithLookupStrategy(LookupStrategy(7)))
+
+		//then
+		assert.Error(t, err)
+	})
+}
+
+func BenchmarkDay5Solver(b *testing.B) {
+	random := rand.New(rand.NewPCG(39, 1))
+	ranges, ingredients := newRandomInput(random, 100_000, 1_000_000, 1_000_000_000)
+	input := formatInput(ranges, ingredients)
+
+	strategies := map[string]LookupStrategy{
+		"binary search": LookupBinarySearch,
+		"interval tree": LookupIntervalTree,
+	}
+
+	for _, name := range []string{"binary search", "interval tree"} {
+		b.Run(fmt.Sprintf("100k ranges and 1M queries using %s", name), func(b *testing.B) {
+			solver, _ := NewDay5Solver(nil, WithLookupStrategy(strategies[name]))
+			b.ReportAllocs()
+			for b.Loop() {
+				_, _ = solver.Solve(context.Background(), strings.NewReader(input))
+			}
+		})
+	}
+}
+
+func newRandomInput(random *rand.Rand, numOfRanges int, numOfIngredients int, maxIngredient IngredientID) ([]IngredientRange, []IngredientID) {
+	ranges := make([]IngredientRange, 0, numOfRanges)
+	maxLength := max(maxIngredient/IngredientID(numOfRanges), 1)
+	for range numOfRanges {
+		min := random.Uint64N(maxIngredient)
+		ranges = append(ranges, IngredientRange{Min: min, Max: min + random.Uint64N(maxLength)})
+	}
+	ingredients := make([]IngredientID, 0, numOfIngredients)
+	for range numOfIngredients {
+		ingredients = append(ingredients, random.Uint64N(maxIngredient+maxLength))
+	}
+	return ranges, ingredients
+}
+
+func formatInput(ranges []IngredientRange, ingredients []IngredientID) string {
+	var builder strings.Builder
+	for _, rang := range ranges {
+		fmt.Fprintf(&builder, "%d-%d\n", rang.Min, rang.Max)
+	}
+	builder.WriteByte('\n')
+	for _, ingredient := range ingredients {
+		fmt.Fprintf(&builder, "%d\n", ingredient)
+	}
+	return builder.String()
+}
+
+func TestIngredientRanges_merge(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		ranges           IngredientRanges
+		coalesceAdjacent bool
+		expected         IngredientRanges
+	}{
+		{name: "no ranges", ranges: IngredientRanges{}, expected: IngredientRanges{}},
+		{name: "single range", ranges: IngredientRanges{{Min: 3, Max: 5}}, expected: IngredientRanges{{Min: 3, Max: 5}}},
+		{name: "overlapping ranges", ranges: IngredientRanges{{Min: 12, Max: 18}, {Min: 3, Max: 5}, {Min: 10, Max: 14}, {Min: 16, Max: 20}}, expected: IngredientRanges{{Min: 3, Max: 5}, {Min: 10, Max: 20}}},
+		{name: "nested ranges", ranges: IngredientRanges{{Min: 1, Max: 10}, {Min: 2, Max: 3}}, expected: IngredientRanges{{Min: 1, Max: 10}}},
+		{name: "adjacent ranges", ranges: IngredientRanges{{Min: 4, Max: 6}, {Min: 1, Max: 3}}, expected: IngredientRanges{{Min: 1, Max: 3}, {Min: 4, Max: 6}}},
+		{name: "adjacent ranges coalesced", ranges: IngredientRanges{{Min: 4, Max: 6}, {Min: 1, Max: 3}, {Min: 8, Max: 9}}, coalesceAdjacent: true, expected: IngredientRanges{{Min: 1, Max: 6}, {Min: 8, Max: 9}}},
+		{name: "range ending at the largest integer coalesced", ranges: IngredientRanges{{Min: 0, Max: math.MaxUint64}, {Min: 5, Max: 6}}, coalesceAdjacent: true, expected: IngredientRanges{{Min: 0, Max: math.MaxUint64}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Merges %s", tt.name), func(t *testing.T) {
+			t.Parallel()
+			//given
+			ranges := tt.ranges
+
+			//when
+			ranges.merge(tt.coalesceAdjacent)
+
+			//then
+			assert.Equal(t, tt.expected, ranges)
+		})
+	}
+}
+
+func TestDay5Solver_parsing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Merges the ranges when there is no blank line before the ingredients", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+		input := "3-5\n10-14\n16-20\n12-18\n1\n5\n8\n11\n17\n32"
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result.FreshIngredientsCount)
+		assert.Equal(t, big.NewInt(14), result.AvailableIngredientsCount)
+	})
+
+	t.Run("Merges the ranges when there are no ingredients", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+		input := "3-5\n4-8\n"
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 0, result.FreshIngredientsCount)
+		assert.Equal(t, big.NewInt(6), result.AvailableIngredientsCount)
+	})
+
+	t.Run("Solves an empty input", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(""))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, Solution{FreshIngredientsCount: 0, AvailableIngredientsCount: big.NewInt(0), RedundantRanges: nil}, result)
+	})
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected InputParseError
+	}{
+		{name: "range without separator", input: "3-5\n10-14\n\n7\n8-9", expected: InputParseError{Line: 5, Reason: "ingredient '8-9' should be a valid integer"}},
+		{name: "range with text", input: "3-5\n1a-14", expected: InputParseError{Line: 2, Reason: "ingredient range '1a-14' should contain valid integers"}},
+		{name: "reversed range", input: "3-5\n14-10", expected: InputParseError{Line: 2, Reason: "ingredient range '14-10' has its min above its max"}},
+		{name: "negative min", input: "-3-5", expected: InputParseError{Line: 1, Reason: "ingredient range '-3-5' can not have negative boundaries"}},
+		{name: "negative max", input: "1-3\n3--5", expected: InputParseError{Line: 2, Reason: "ingredient range '3--5' can not have negative boundaries"}},
+		{name: "invalid ingredient", input: "3-5\n\n4\nfour", expected: InputParseError{Line: 4, Reason: "ingredient 'four' should be a valid integer"}},
+		{name: "range beyond the largest ID", input: "3-5\n1-18446744073709551616", expected: InputParseError{Line: 2, Reason: "ingredient range '1-18446744073709551616' goes beyond the largest ingredient ID 18446744073709551615"}},
+		{name: "ingredient beyond the largest ID", input: "3-5\n\n99999999999999999999", expected: InputParseError{Line: 3, Reason: "ingredient '99999999999999999999' is above the largest ingredient ID 18446744073709551615"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Reports the line of a %s", tt.name), func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, _ := NewDay5Solver(nil)
+
+			//when
+			_, err := solver.Solve(context.Background(), strings.NewReader(tt.input))
+
+			//then
+			var parseErr InputParseError
+			assert.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.expected, parseErr)
+		})
+	}
+}
+
+func TestDay5Solver_explanations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Explains which ranges make every ingredient fresh", func(t *testing.T) {
+		t.Parallel()
+		//given
+		var builder strings.Builder
+		encoder := json.NewEncoder(&builder)
+		solver, _ := NewDay5Solver(nil, WithExplanationHandler(func(explanation IngredientExplanation) error {
+			return encoder.Encode(explanation)
+		}))
+		input := "3-5\n10-14\n16-20\n12-18\n\n1\n5\n17"
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.FreshIngredientsCount)
+		lines := strings.Split(strings.TrimSpace(builder.String()), "\n")
+		assert.Len(t, lines, 3)
+		assert.JSONEq(t, `{"line":6,"ingredient":1,"fresh":false,"ranges":[],"mergedRange":null}`, lines[0])
+		assert.JSONEq(t, `{"line":7,"ingredient":5,"fresh":true,"ranges":[{"line":1,"min":3,"max":5}],"mergedRange":{"min":3,"max":5}}`, lines[1])
+		assert.JSONEq(t, `{"line":8,"ingredient":17,"fresh":true,"ranges":[{"line":3,"min":16,"max":20},{"line":4,"min":12,"max":18}],"mergedRange":{"min":10,"max":20}}`, lines[2])
+	})
+
+	t.Run("Stops at the first error of the handler", func(t *testing.T) {
+		t.Parallel()
+		//given
+		handlerErr := errors.New("disk full")
+		solver, _ := NewDay5Solver(nil, WithExplanationHandler(func(explanation IngredientExplanation) error {
+			return handlerErr
+		}))
+
+		//when
+		_, err := solver.Solve(context.Background(), strings.NewReader("3-5\n\n4"))
+
+		//then
+		assert.ErrorIs(t, err, handlerErr)
+	})
+
+	t.Run("Reports the ranges that are covered by other ranges", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil, WithRedundantRangeReport())
+		input := "1-10\n2-4\n8-15\n14-20\n15-16\n30-31\n30-31\n40-18446744073709551615\n50-60\n"
+
+		//when
+		result, err := solver.Solve(context.Background(), strings.NewReader(input))
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, []SourceRange{
+			{Line: 2, IngredientRange: IngredientRange{Min: 2, Max: 4}},
+			{Line: 5, IngredientRange: IngredientRange{Min: 15, Max: 16}},
+			{Line: 6, IngredientRange: IngredientRange{Min: 30, Max: 31}},
+			{Line: 7, IngredientRange: IngredientRange{Min: 30, Max: 31}},
+			{Line: 9, IngredientRange: IngredientRange{Min: 50, Max: 60}},
+		}, result.RedundantRanges)
+	})
+}
+
+func TestDay5Solver_serveQueries(t *testing.T) {
+	t.Parallel()
+
+	for name, strategy := range map[string]LookupStrategy{"binary search": LookupBinarySearch, "interval tree": LookupIntervalTree} {
+		t.Run(fmt.Sprintf("Answers every query with a running count using %s", name), func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, _ := NewDay5Solver(nil, WithLookupStrategy(strategy))
+			inventory, _, _, err := solver.LoadInventory(context.Background(), strings.NewReader("3-5\n10-14\n16-20\n12-18\n\n1\n5"))
+			assert.NoError(t, err)
+			answers := []QueryAnswer{}
+
+			//when
+			last, err := solver.ServeQueries(context.Background(), inventory, strings.NewReader("1\n5\n\neight\n17\n32\n"), 0, func(answer QueryAnswer) error {
+				answers = append(answers, answer)
+				return nil
+			})
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, []QueryAnswer{
+				{Line: 1, Ingredient: 1, IsFresh: false, FreshCount: 0, QueryCount: 1},
+				{Line: 2, Ingredient: 5, IsFresh: true, FreshCount: 1, QueryCount: 2},
+				{Line: 4, Ingredient: 0, IsFresh: false, Error: "ingredient 'eight' should be a valid integer", FreshCount: 1, QueryCount: 3},
+				{Line: 5, Ingredient: 17, IsFresh: true, FreshCount: 2, QueryCount: 4},
+				{Line: 6, Ingredient: 32, IsFresh: false, FreshCount: 2, QueryCount: 5},
+			}, answers)
+			assert.Equal(t, answers[len(answers)-1], last)
+		})
+	}
+
+	t.Run("Answers a query before the next one is written and stops when the context is cancelled", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+		inventory, _, _, _ := solver.LoadInventory(context.Background(), strings.NewReader("3-5"))
+		reader, writer := io.Pipe()
+		defer writer.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		answers := make(chan QueryAnswer, 1)
+		served := make(chan error)
+
+		//when
+		go func() {
+			_, err := solver.ServeQueries(ctx, inventory, reader, 0, func(answer QueryAnswer) error {
+				answers <- answer
+				return nil
+			})
+			served <- err
+		}()
+		_, _ = writer.Write([]byte("4\n"))
+		answer := <-answers
+		cancel()
+
+		//then
+		assert.Equal(t, QueryAnswer{Line: 1, Ingredient: 4, IsFresh: true, FreshCount: 1, QueryCount: 1}, answer)
+		assert.ErrorIs(t, <-served, context.Canceled)
+	})
+
+	t.Run("Stops at the first error of the handler", func(t *testing.T) {
+		t.Parallel()
+		//given
+		handlerErr := errors.New("broken pipe")
+		solver, _ := NewDay5Solver(nil)
+		inventory, _, _, _ := solver.LoadInventory(context.Background(), strings.NewReader("3-5"))
+
+		//when
+		_, err := solver.ServeQueries(context.Background(), inventory, strings.NewReader("4\n5\n"), 0, func(answer QueryAnswer) error {
+			return handlerErr
+		})
+
+		//then
+		assert.ErrorIs(t, err, handlerErr)
+	})
+
+	testCases := []struct {
+		name          string
+		input         string
+		consumedLines int
+		expectedLines []int
+	}{
+		{name: "a blank line", input: "3-5\n10-14\n\n4\n11\n20\n", consumedLines: 3, expectedLines: []int{4, 5, 6}},
+		{name: "the first query", input: "3-5\r\n10-14\r\n4\r\n11\r\n20", consumedLines: 2, expectedLines: []int{3, 4, 5}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Serves the queries of the same stream as the ranges when the ranges end with %s", tt.name), func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, _ := NewDay5Solver(nil)
+			inventory, rest, consumedLines, err := solver.LoadInventory(context.Background(), strings.NewReader(tt.input))
+			assert.NoError(t, err)
+			ingredients := []IngredientID{}
+			lines := []int{}
+
+			//when
+			last, err := solver.ServeQueries(context.Background(), inventory, rest, consumedLines, func(answer QueryAnswer) error {
+				ingredients = append(ingredients, answer.Ingredient)
+				lines = append(lines, answer.Line)
+				return nil
+			})
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.consumedLines, consumedLines)
+			assert.Equal(t, []IngredientID{4, 11, 20}, ingredients)
+			assert.Equal(t, tt.expectedLines, lines)
+			assert.Equal(t, 3, last.QueryCount)
+			assert.Equal(t, 2, last.FreshCount)
+		})
+	}
+
+	t.Run("Loads the ranges of an input without queries", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+
+		//when
+		inventory, rest, consumedLines, err := solver.LoadInventory(context.Background(), strings.NewReader("3-5\n10-14\n"))
+		remaining, _ := io.ReadAll(rest)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, 2, consumedLines)
+		assert.True(t, inventory.isFreshIngredient(14))
+		assert.Empty(t, remaining)
+	})
+
+	t.Run("Reports the line of an invalid range while loading the inventory", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay5Solver(nil)
+
+		//when
+		_, _, _, err := solver.LoadInventory(context.Background(), strings.NewReader("3-5\n9-4"))
+
+		//then
+		assert.Equal(t, InputParseError{Line: 2, Reason: "ingredient range '9-4' has its min above its max"}, err)
+	})
+}
+
+func TestDay5Solver_largeRanges(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		input         string
+		expectedFresh int
+		expected      string
+	}{
+		{name: "15 digit IDs", input: "100000000000000-999999999999999\n\n500000000000000\n1", expectedFresh: 1, expected: "900000000000000"},
+		{name: "IDs beyond the signed 64 bit integers", input: "9223372036854775807-18446744073709551614\n\n18446744073709551614\n18446744073709551615", expectedFresh: 1, expected: "9223372036854775808"},
+		{name: "every ID", input: "0-9223372036854775807\n9223372036854775808-18446744073709551615\n\n18446744073709551615", expectedFresh: 1, expected: "18446744073709551616"},
+		{name: "every ID in a single range", input: "0-18446744073709551615\n5-10", expectedFresh: 0, expected: "18446744073709551616"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Counts the available ingredients of %s", tt.name), func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, _ := NewDay5Solver(nil)
+
+			//when
+			result, err := solver.Solve(context.Background(), strings.NewReader(tt.input))
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFresh, result.FreshIngredientsCount)
+			assert.Equal(t, tt.expected, result.AvailableIngredientsCount.String())
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package day05
+
+// intervalTree is an AVL tree of ingredient ranges ordered by their min, every node also keeps the largest
+// max of its subtree so a lookup can skip the subtrees that end before the ingredient. Overlapping ranges
+// can be inserted in any order and at any time, the tree never needs the ranges to be merged.
+type intervalTree struct {
+	root *intervalNode
+	size int
+}
+
+type intervalNode struct {
+	source SourceRange
+	maxEnd IngredientID
+	height int
+	left   *intervalNode
+	right  *intervalNode
+}
+
+// insert adds the range to the tree
+//
+// NOTE: Time complexity O(log n)
+func (t *intervalTree) insert(source SourceRange) {
+	t.root = t.root.insert(source)
+	t.size++
+}
+
+// contains tells if any of the ranges contains the ingredient
+//
+// NOTE: Time complexity O(log n)
+func (t *intervalTree) contains(ingredient IngredientID) bool {
+	node := t.root
+	for node != nil {
+		if node.source.Contains(ingredient) {
+			return true
+		}
+		// when the left subtree reaches the ingredient but none of its ranges contain it, then every one of those
+		// ranges starts after the ingredient and so does every range on the right, so the right can be skipped
+		if node.left != nil && node.left.maxEnd >= ingredient {
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	return false
+}
+
+// findAll visits every range that contains the ingredient
+//
+// NOTE: Time complexity O(log n + k) where k is the number of ranges containing the ingredient
+func (t *intervalTree) findAll(ingredient IngredientID, visit func(source SourceRange)) {
+	t.root.findAll(ingredient, visit)
+}
+
+func (n *intervalNode) findAll(ingredient IngredientID, visit func(source SourceRange)) {
+	if n == nil || n.maxEnd < ingredient {
+		return
+	}
+	n.left.findAll(ingredient, visit)
+	if n.source.Contains(ingredient) {
+		visit(n.source)
+	}
+	// every range on the right starts at or after this one, so they can only match when this one does not start after the ingredient
+	if n.source.Min <= ingredient {
+		n.right.findAll(ingredient, visit)
+	}
+}
+
+func (n *intervalNode) insert(source SourceRange) *intervalNode {
+	if n == nil {
+		return &intervalNode{source: source, maxEnd: source.Max, height: 1}
+	}
+	if source.Min < n.source.Min {
+		n.left = n.left.insert(source)
+	} else {
+		n.right = n.right.insert(source)
+	}
+	return n.rebalance()
+}
+
+func (n *intervalNode) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *intervalNode) update() {
+	n.height = 1 + max(n.left.getHeight(), n.right.getHeight())
+	n.maxEnd = n.source.Max
+	if n.left != nil {
+		n.maxEnd = max(n.maxEnd, n.left.maxEnd)
+	}
+	if n.right != nil {
+		n.maxEnd = max(n.maxEnd, n.right.maxEnd)
+	}
+}
+
+func (n *intervalNode) rebalance() *intervalNode {
+	n.update()
+	balance := n.left.getHeight() - n.right.getHeight()
+	switch {
+	case balance > 1:
+		if n.left.left.getHeight() < n.left.right.getHeight() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case balance < -1:
+		if n.right.right.getHeight() < n.right.left.getHeight() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	default:
+		return n
+	}
+}
+
+func (n *intervalNode) rotateLeft() *intervalNode {
+	pivot := n.right
+	n.right = pivot.left
+	pivot.left = n
+	n.update()
+	pivot.update()
+	return pivot
+}
+
+func (n *intervalNode) rotateRight() *intervalNode {
+	pivot := n.left
+	n.left = pivot.right
+	pivot.right = n
+	n.update()
+	pivot.update()
+	return pivot
+}
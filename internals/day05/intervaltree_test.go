@@ -0,0 +1,79 @@
+package day05
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntervalTree(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Finds the ingredients covered by overlapping ranges inserted in any order", func(t *testing.T) {
+		t.Parallel()
+		//given
+		random := rand.New(rand.NewPCG(39, 2))
+		ranges, ingredients := newRandomInput(random, 2000, 5000, 50000)
+		tree := &intervalTree{}
+
+		//when
+		for idx, rang := range ranges {
+			tree.insert(SourceRange{Line: idx + 1, IngredientRange: rang})
+		}
+
+		//then
+		assert.Equal(t, len(ranges), tree.size)
+		for _, ingredient := range ingredients {
+			expected := false
+			for _, rang := range ranges {
+				if rang.Min <= ingredient && ingredient <= rang.Max {
+					expected = true
+					break
+				}
+			}
+			assert.Equal(t, expected, tree.contains(ingredient), "ingredient %d", ingredient)
+		}
+		for _, ingredient := range ingredients[:500] {
+			expected := []int{}
+			for idx, rang := range ranges {
+				if rang.Contains(ingredient) {
+					expected = append(expected, idx+1)
+				}
+			}
+			found := []int{}
+			tree.findAll(ingredient, func(source SourceRange) {
+				found = append(found, source.Line)
+			})
+			assert.ElementsMatch(t, expected, found, "ingredient %d", ingredient)
+		}
+	})
+
+	t.Run("Stays balanced when the ranges are inserted in sorted order", func(t *testing.T) {
+		t.Parallel()
+		//given
+		tree := &intervalTree{}
+
+		//when
+		for i := range IngredientID(10000) {
+			tree.insert(SourceRange{Line: int(i) + 1, IngredientRange: IngredientRange{Min: i * 10, Max: i*10 + 5}})
+		}
+
+		//then
+		// an AVL tree is never higher than about 1.44 log2(n)
+		assert.LessOrEqual(t, float64(tree.root.height), 1.45*math.Log2(10000)+1)
+		assert.True(t, tree.contains(99995))
+		assert.False(t, tree.contains(99996))
+		assert.False(t, tree.contains(6))
+	})
+
+	t.Run("Finds nothing in an empty tree", func(t *testing.T) {
+		t.Parallel()
+		//given
+		tree := &intervalTree{}
+
+		//then
+		assert.False(t, tree.contains(0))
+	})
+}
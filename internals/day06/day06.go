@@ -3,67 +3,174 @@ package day06
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"slices"
 	"strconv"
 	"strings"
 
 	"go.uber.org/zap"
 )
 
-type PuzzleInterpreter int
+type Day6Solver struct {
+	logger    *zap.Logger
+	operators *OperatorRegistry
+}
 
-const (
-	HumanMath PuzzleInterpreter = iota
-	CephalopodMath
-)
+type Option func(*Day6Solver) error
 
-type Day6Solver struct {
-	logger *zap.Logger
+// WithOperators replaces the default operators, only the operators of the registry are recognised on the operator line
+func WithOperators(operators *OperatorRegistry) Option {
+	return func(d *Day6Solver) error {
+		if operators == nil || len(operators.operators) == 0 {
+			return fmt.Errorf("operator registry can not be empty")
+		}
+		d.operators = operators
+		return nil
+	}
 }
 
-func NewDay6Solver(logger *zap.Logger) (*Day6Solver, error) {
+func NewDay6Solver(logger *zap.Logger, opts ...Option) (*Day6Solver, error) {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
 	solver := &Day6Solver{
-		logger: logger,
+		logger:    logger,
+		operators: DefaultOperators(),
+	}
+	for _, opt := range opts {
+		if err := opt(solver); err != nil {
+			return nil, err
+		}
 	}
 	return solver, nil
 }
 
-func (d *Day6Solver) Solve(ctx context.Context, reader io.Reader, puzzleInterpreter PuzzleInterpreter) (int, error) {
-	numberLines, operatorLine := readLines(reader)
+// Solve returns the grand total as a big integer, the problems are evaluated with ints and only the ones
+// that do not fit into an int are evaluated again with big integers
+func (d *Day6Solver) Solve(ctx context.Context, reader io.Reader, puzzleInterpreter PuzzleInterpreter) (*big.Int, error) {
+	if puzzleInterpreter == nil {
+		return nil, fmt.Errorf("missing puzzle interpreter")
+	}
+	_, _, problems, err := d.readProblems(reader)
+	if err != nil {
+		return nil, err
+	}
+	sum := new(big.Int)
+	for _, problem := range problems {
+		_, result, err := problem.solve(puzzleInterpreter, d.operators)
+		if err != nil {
+			return nil, err
+		}
+		sum.Add(sum, result)
+	}
+	return sum, nil
+}
+
+// Breakdown solves the worksheet and keeps how every problem was read and what it came to
+func (d *Day6Solver) Breakdown(ctx context.Context, reader io.Reader, puzzleInterpreter PuzzleInterpreter) (Worksheet, error) {
+	if puzzleInterpreter == nil {
+		return Worksheet{}, fmt.Errorf("missing puzzle interpreter")
+	}
+	numberLines, operatorLine, problems, err := d.readProblems(reader)
+	if err != nil {
+		return Worksheet{}, err
+	}
+	breakdowns := make([]ProblemBreakdown, 0, len(problems))
+	sum := new(big.Int)
+	for _, problem := range problems {
+		numbers, result, err := problem.solve(puzzleInterpreter, d.operators)
+		if err != nil {
+			return Worksheet{}, err
+		}
+		breakdowns = append(breakdowns, ProblemBreakdown{
+			Operator: problem.section.operator,
+			Start:    problem.section.start,
+			End:      problem.section.end,
+			Operands: numbers.bigInts(),
+			Result:   result,
+		})
+		sum.Add(sum, result)
+	}
+	return Worksheet{
+		NumberLines:  numberLines,
+		OperatorLine: operatorLine,
+		Problems:     breakdowns,
+		Total:        sum,
+	}, nil
+}
+
+// readProblems reads the worksheet and splits it to problems
+func (d *Day6Solver) readProblems(reader io.Reader) ([]string, string, []Problem, error) {
+	numberLines, operatorLine, err := readLines(reader, d.operators)
+	if err != nil {
+		return nil, "", nil, err
+	}
 	d.logger.Debug("read input to operator line", zap.String("operatorLine", operatorLine))
 	d.logger.Debug("read input to number lines", zap.String("numberLines", fmt.Sprintf("%+v", numberLines)))
-	sections := parseOperators(operatorLine)
-	d.logger.Debug("split operator line to sections", zap.String("sections", fmt.Sprintf("%+v", sections)))
-	problems := createProblems(sections, numberLines)
-	d.logger.Debug("converted number lines to problems", zap.String("problems", fmt.Sprintf("%+v", problems)))
-	solution, err := solveProblems(problems, puzzleInterpreter)
+	numberRows, operatorRow := padLines(numberLines, operatorLine)
+	sections, err := segmentColumns(numberRows, operatorRow)
 	if err != nil {
-		return 0, err
+		return nil, "", nil, err
 	}
-	return solution, nil
+	d.logger.Debug("split worksheet to sections", zap.String("sections", fmt.Sprintf("%+v", sections)))
+	problems := createProblems(sections, numberRows)
+	d.logger.Debug("converted number lines to problems", zap.String("problems", fmt.Sprintf("%+v", problems)))
+	return numberLines, operatorLine, problems, nil
 }
 
-func readLines(reader io.Reader) ([]string, string) {
+// readLines splits the worksheet to the number lines and the operator line, which has to be the last line
+// that is not blank
+func readLines(reader io.Reader, operators *OperatorRegistry) ([]string, string, error) {
 	numLines := make([]string, 0)
 	operatorLine := ""
+	operatorLineNumber := 0
 
 	scanner := bufio.NewScanner(reader)
 
-	for scanner.Scan() {
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
 		line := scanner.Text()
-		isLastLine := strings.ContainsAny(line, `+*`)
-		if isLastLine {
+		isBlank := strings.TrimSpace(line) == ""
+		switch {
+		case operatorLineNumber > 0 && !isBlank:
+			return nil, "", fmt.Errorf("line %d comes after the operator line at line %d", lineNumber, operatorLineNumber)
+		case operatorLineNumber > 0:
+			continue
+		case operators.isOperatorLine(line):
 			operatorLine = line
-		} else {
+			operatorLineNumber = lineNumber
+		default:
 			numLines = append(numLines, line)
 		}
 	}
 
-	return numLines, operatorLine
+	if err := scanner.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if operatorLineNumber == 0 {
+		return nil, "", fmt.Errorf("worksheet has no operator line")
+	}
+
+	return numLines, operatorLine, nil
+}
+
+// padLines turns the lines to runes and pads them with spaces to the widest line, so every column can be
+// read on every line even when the lines are ragged or have characters wider than a byte
+func padLines(numberLines []string, operatorLine string) ([][]rune, []rune) {
+	rows := make([][]rune, 0, len(numberLines)+1)
+	width := 0
+	for _, line := range append(slices.Clone(numberLines), operatorLine) {
+		row := []rune(line)
+		width = max(width, len(row))
+		rows = append(rows, row)
+	}
+	for idx, row := range rows {
+		rows[idx] = append(row, []rune(strings.Repeat(" ", width-len(row)))...)
+	}
+	return rows[:len(rows)-1], rows[len(rows)-1]
 }
 
 type Section struct {
@@ -72,144 +179,152 @@ type Section struct {
 	end      int
 }
 
-func parseOperators(operatorLine string) []Section {
+// segmentColumns splits the worksheet to sections at the columns that are blank on every line including the
+// operator line, a section is start up to but not including end and has to have exactly one operator
+func segmentColumns(numberRows [][]rune, operatorRow []rune) ([]Section, error) {
+	isBlankColumn := func(column int) bool {
+		if operatorRow[column] != ' ' {
+			return false
+		}
+		for _, row := range numberRows {
+			if row[column] != ' ' {
+				return false
+			}
+		}
+		return true
+	}
+
 	sections := []Section{}
-	start := 0
-	for i, run := range operatorLine {
-		char := string(run)
-		isOperator := char == "*" || char == "+"
-		isLastChar := i == len(operatorLine)-1
-		if isOperator && i > 0 {
-			sections = append(sections, Section{
-				operator: string(operatorLine[start]),
-				start:    start,
-				end:      i - 1,
-			})
-			start = i
-		} else if isLastChar {
-			sections = append(sections, Section{
-				operator: string(operatorLine[start]),
-				start:    start,
-				end:      i + 1,
-			})
-		}
-	}
-	return sections
-}
-
-func createProblems(sections []Section, numberLines []string) []Problem {
+	for column := 0; column < len(operatorRow); {
+		if isBlankColumn(column) {
+			column++
+			continue
+		}
+		start := column
+		for column < len(operatorRow) && !isBlankColumn(column) {
+			column++
+		}
+		symbols := strings.Fields(string(operatorRow[start:column]))
+		if len(symbols) != 1 {
+			return nil, fmt.Errorf("problem at columns %d-%d should have a single operator but has %d", start, column-1, len(symbols))
+		}
+		sections = append(sections, Section{
+			operator: symbols[0],
+			start:    start,
+			end:      column,
+		})
+	}
+	return sections, nil
+}
+
+func createProblems(sections []Section, numberRows [][]rune) []Problem {
 	problems := []Problem{}
 	for _, section := range sections {
 		problem := Problem{
-			numberRows: []string{},
-			operator:   section.operator,
+			numberRows: [][]rune{},
+			section:    section,
 		}
-		for _, numLine := range numberLines {
-			problem.numberRows = append(problem.numberRows, numLine[section.start:section.end])
+		for _, numberRow := range numberRows {
+			problem.numberRows = append(problem.numberRows, numberRow[section.start:section.end])
 		}
 		problems = append(problems, problem)
 	}
 	return problems
 }
 
-func solveProblems(problems []Problem, puzzleInterpreter PuzzleInterpreter) (int, error) {
-	sum := 0
-	for _, problem := range problems {
-		result, err := problem.solve(puzzleInterpreter)
-		if err != nil {
-			return 0, err
-		}
-		sum += result
-	}
-	return sum, nil
+// Problem has the padded rows of a single section, so every row is as wide as the section
+type Problem struct {
+	numberRows [][]rune
+	section    Section
 }
 
-type Problem struct {
-	numberRows []string
-	operator   string
+// operands are the numbers of a problem, they are kept as ints unless one of them does not fit into an int
+type operands struct {
+	small []int
+	big   []*big.Int
 }
 
-func (p *Problem) getWidth() int {
-	width := 0
-	for _, numberRow := range p.numberRows {
-		if len(numberRow) > width {
-			width = len(numberRow)
-		}
+func (o operands) bigInts() []*big.Int {
+	if o.big != nil {
+		return o.big
 	}
-	return width
+	numbers := make([]*big.Int, 0, len(o.small))
+	for _, number := range o.small {
+		numbers = append(numbers, big.NewInt(int64(number)))
+	}
+	return numbers
 }
 
-func (p *Problem) parseNumbersHorizontally() ([]int, error) {
-	nums := []int{}
-	for _, numAsStr := range p.numberRows {
-		numAsStr = strings.TrimSpace(numAsStr)
+// parseNumbers parses what the interpreter reads out of the rows, the blank numbers are skipped as a problem
+// can be shorter than the others. A tall column can have more digits than an int can hold, so once a number
+// is out of range all of them are parsed again as big integers.
+func (p *Problem) parseNumbers(puzzleInterpreter PuzzleInterpreter) (operands, error) {
+	numsAsStr := puzzleInterpreter.ReadNumbers(p.numberRows)
+	nums := make([]int, 0, len(numsAsStr))
+	for _, numAsStr := range numsAsStr {
+		if numAsStr == "" {
+			continue
+		}
 		num, err := strconv.Atoi(numAsStr)
+		if errors.Is(err, strconv.ErrRange) {
+			return parseBigNumbers(numsAsStr)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("invalid integer '%s'", numAsStr)
+			return operands{}, fmt.Errorf("invalid integer '%s'", numAsStr)
 		}
 		nums = append(nums, num)
 	}
-	return nums, nil
+	return operands{small: nums}, nil
 }
 
-func (p *Problem) parseNumbersVertically() ([]int, error) {
-	nums := []int{}
-	for i := 0; i < p.getWidth(); i++ {
-		var builder strings.Builder
-		for j := 0; j < len(p.numberRows); j++ {
-			char := p.numberRows[j][i]
-			if char == ' ' {
-				continue
-			}
-			builder.WriteByte(char)
+func parseBigNumbers(numsAsStr []string) (operands, error) {
+	nums := make([]*big.Int, 0, len(numsAsStr))
+	for _, numAsStr := range numsAsStr {
+		if numAsStr == "" {
+			continue
 		}
-		numAsStr := builder.String()
-		num, err := strconv.Atoi(numAsStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid integer '%s'", numAsStr)
+		num, ok := new(big.Int).SetString(numAsStr, 10)
+		if !ok {
+			return operands{}, fmt.Errorf("invalid integer '%s'", numAsStr)
 		}
 		nums = append(nums, num)
 	}
-	return nums, nil
+	return operands{big: nums}, nil
 }
 
-func (p *Problem) parseNumberRowsToNums(puzzleInterpreter PuzzleInterpreter) ([]int, error) {
-	switch puzzleInterpreter {
-	case HumanMath:
-		return p.parseNumbersHorizontally()
-	case CephalopodMath:
-		return p.parseNumbersVertically()
-	}
-	return nil, fmt.Errorf("invalid interpreter '%d'", puzzleInterpreter)
-}
-
-func (p *Problem) solve(puzzleInterpreter PuzzleInterpreter) (int, error) {
-	numbers, err := p.parseNumberRowsToNums(puzzleInterpreter)
+// solve applies the operator to the numbers in the order the interpreter reads them
+func (p *Problem) solve(puzzleInterpreter PuzzleInterpreter, operators *OperatorRegistry) (operands, *big.Int, error) {
+	numbers, err := p.parseNumbers(puzzleInterpreter)
 	if err != nil {
-		return 0, err
+		return operands{}, nil, p.wrapErr(err)
 	}
-	switch p.operator {
-	case "*":
-		return multiplyAll(numbers), nil
-	case "+":
-		return sumAll(numbers), nil
-	default:
-		return 0, fmt.Errorf("invalid operator %s", p.operator)
+	operator, ok := operators.Lookup(p.section.operator)
+	if !ok {
+		return operands{}, nil, p.wrapErr(fmt.Errorf("invalid operator %s", p.section.operator))
+	}
+	result, err := evaluate(operator, numbers)
+	if err != nil {
+		return operands{}, nil, p.wrapErr(err)
 	}
+	return numbers, result, nil
 }
 
-func multiplyAll(numbers []int) int {
-	sum := 1
-	for _, number := range numbers {
-		sum *= number
-	}
-	return sum
+func (p *Problem) wrapErr(err error) error {
+	return fmt.Errorf("problem at columns %d-%d: %w", p.section.start, p.section.end-1, err)
 }
 
-func sumAll(numbers []int) int {
-	sum := 0
-	for _, number := range numbers {
-		sum += number
+// evaluate folds the numbers as ints and only falls back to big integers when one of the numbers
+// or one of the partial results does not fit into an int
+func evaluate(operator Operator, numbers operands) (*big.Int, error) {
+	if numbers.big != nil {
+		return operator.foldBig(numbers.big)
+	}
+	result, err := operator.fold(numbers.small)
+	if errors.Is(err, ErrOverflow) {
+		return operator.foldBig(numbers.bigInts())
+	}
+	if err != nil {
+		return nil, err
 	}
-	return sum
+	return big.NewInt(int64(result)), nil
 }
@@ -2,6 +2,7 @@ package day06
 
 import (
 	"context"
+	"math/big"
 	"strings"
 	"testing"
 
@@ -27,7 +28,7 @@ func TestDay6Solver(t *testing.T) {
 
 		//then
 		assert.NoError(t, err)
-		assert.Equal(t, 4277556, solution)
+		assert.Equal(t, big.NewInt(4277556), solution)
 	})
 
 	t.Run("Solves day 6 challenge part 2", func(t *testing.T) {
@@ -46,6 +47,138 @@ func TestDay6Solver(t *testing.T) {
 
 		//then
 		assert.NoError(t, err)
-		assert.Equal(t, 3263827, solution)
+		assert.Equal(t, big.NewInt(3263827), solution)
 	})
+
+	t.Run("Solves problems whose products overflow an int", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay6Solver(nil)
+		input := `9223372036854775807 3
+9223372036854775807 4
+*                   +`
+		expected, _ := new(big.Int).SetString("85070591730234615847396907784232501249", 10)
+		expected.Add(expected, big.NewInt(7))
+
+		//when
+		solution, err := solver.Solve(context.Background(), strings.NewReader(input), HumanMath)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, expected, solution)
+	})
+
+	t.Run("Solves problems whose columns are taller than the digits of an int", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay6Solver(nil)
+		// every column reads as 25 nines, which do not fit into an int even before they are multiplied
+		input := strings.Repeat("99 1\n", 25) + "*  +"
+		column, _ := new(big.Int).SetString(strings.Repeat("9", 25), 10)
+		ones, _ := new(big.Int).SetString(strings.Repeat("1", 25), 10)
+		expected := new(big.Int).Mul(column, column)
+		expected.Add(expected, ones)
+
+		//when
+		solution, err := solver.Solve(context.Background(), strings.NewReader(input), CephalopodMath)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, expected, solution)
+	})
+
+	t.Run("Adds up a grand total that overflows an int", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay6Solver(nil)
+		input := `9223372036854775807 9223372036854775807
+*                   *                  `
+
+		//when
+		solution, err := solver.Solve(context.Background(), strings.NewReader(input), HumanMath)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, "18446744073709551614", solution.String())
+	})
+}
+
+func TestDay6Solver_segmentation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		interpreter PuzzleInterpreter
+		expected    int64
+	}{
+		{name: "Solves ragged lines read by humans", input: "123 328  51 64\n 45 64  387 23\n  6 98  215 314\n*   +   *   +", interpreter: HumanMath, expected: 4277556},
+		{name: "Solves ragged lines read by cephalopods", input: "123 328  51 64\n 45 64  387 23\n  6 98  215 314\n*   +   *   +", interpreter: CephalopodMath, expected: 3263827},
+		{name: "Solves problems of different heights", input: "1 10\n2\n+ *", interpreter: HumanMath, expected: 13},
+		{name: "Solves an operator wider than its numbers", input: "7 2 4\n3 1 5\nmin +", interpreter: CephalopodMath, expected: 21 + 45},
+		{name: "Solves a worksheet with blank lines after the operator line", input: "1 2\n3 4\n+ *\n\n  \n", interpreter: HumanMath, expected: 4 + 8},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, _ := NewDay6Solver(nil)
+
+			//when
+			solution, err := solver.Solve(context.Background(), strings.NewReader(tt.input), tt.interpreter)
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(tt.expected), solution)
+		})
+	}
+
+	t.Run("Reads columns as characters when the operators are wider than a byte", func(t *testing.T) {
+		t.Parallel()
+		//given
+		registry := DefaultOperators()
+		_ = registry.Register(Operator{Symbol: "×", Apply: multiply})
+		solver, _ := NewDay6Solver(nil, WithOperators(registry))
+		input := "12 5\n 3 6\n×  +"
+
+		//when
+		worksheet, err := solver.Breakdown(context.Background(), strings.NewReader(input), CephalopodMath)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, []ProblemBreakdown{
+			{Operator: "×", Start: 0, End: 2, Operands: bigInts(1, 23), Result: big.NewInt(23)},
+			{Operator: "+", Start: 3, End: 4, Operands: bigInts(56), Result: big.NewInt(56)},
+		}, worksheet.Problems)
+		assert.Equal(t, big.NewInt(79), worksheet.Total)
+	})
+
+	errorCases := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "Fails without an operator line", input: "1 2\n3 4\n", err: "worksheet has no operator line"},
+		{name: "Fails when numbers follow the operator line", input: "1 2\n+ *\n3 4", err: "line 3 comes after the operator line at line 2"},
+		{name: "Fails when a problem has two operators", input: "123\n+ -", err: "problem at columns 0-2 should have a single operator but has 2"},
+		{name: "Fails when a problem has no operator", input: "12 34\n+", err: "problem at columns 3-4 should have a single operator but has 0"},
+		{name: "Fails on a number with a character that is not a digit", input: "1a 2\n3  4\n+  *", err: "problem at columns 0-1: invalid integer '1a'"},
+		{name: "Fails on a number with a multi byte digit", input: "1٣\n+", err: "problem at columns 0-1: invalid integer '1٣'"},
+		{name: "Fails on an invalid number after one that does not fit into an int", input: "99999999999999999999\n1a\n+", err: "problem at columns 0-19: invalid integer '1a'"},
+	}
+
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, _ := NewDay6Solver(nil)
+
+			//when
+			_, err := solver.Solve(context.Background(), strings.NewReader(tt.input), HumanMath)
+
+			//then
+			assert.EqualError(t, err, tt.err)
+		})
+	}
 }
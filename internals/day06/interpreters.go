@@ -0,0 +1,167 @@
+package day06
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PuzzleInterpreter reads the numbers of a single problem out of its rows, every row is as wide as the problem
+// and padded with spaces. The numbers are returned in the order they are folded by the operator, the blank
+// ones are skipped so an interpreter does not have to care about problems that are shorter than the others.
+type PuzzleInterpreter interface {
+	ReadNumbers(rows [][]rune) []string
+}
+
+var (
+	HumanMath      PuzzleInterpreter = RowsTopToBottom{}
+	CephalopodMath PuzzleInterpreter = ColumnsLeftToRight{}
+)
+
+// RowsTopToBottom reads every row as a number starting from the top row
+type RowsTopToBottom struct{}
+
+func (RowsTopToBottom) ReadNumbers(rows [][]rune) []string {
+	numbers := make([]string, 0, len(rows))
+	for _, row := range rows {
+		numbers = append(numbers, strings.TrimSpace(string(row)))
+	}
+	return numbers
+}
+
+// ReversedRows reads every row as a number starting from the top row with the digits of the numbers in
+// reverse, the sign of a number stays in front of it
+type ReversedRows struct{}
+
+func (ReversedRows) ReadNumbers(rows [][]rune) []string {
+	numbers := make([]string, 0, len(rows))
+	for _, row := range rows {
+		number := []rune(strings.TrimSpace(string(row)))
+		digits := number
+		if len(number) > 0 && (number[0] == '-' || number[0] == '+') {
+			digits = number[1:]
+		}
+		slices.Reverse(digits)
+		numbers = append(numbers, string(number))
+	}
+	return numbers
+}
+
+// ColumnsLeftToRight reads every column as a number starting from the leftmost one, with the most
+// significant digit at the top
+type ColumnsLeftToRight struct{}
+
+func (ColumnsLeftToRight) ReadNumbers(rows [][]rune) []string {
+	numbers := make([]string, 0, problemWidth(rows))
+	for column := range problemWidth(rows) {
+		numbers = append(numbers, readColumn(rows, column, false))
+	}
+	return numbers
+}
+
+// ColumnsRightToLeft reads every column as a number starting from the rightmost one, with the most
+// significant digit at the top
+type ColumnsRightToLeft struct{}
+
+func (ColumnsRightToLeft) ReadNumbers(rows [][]rune) []string {
+	numbers := make([]string, 0, problemWidth(rows))
+	for column := problemWidth(rows) - 1; column >= 0; column-- {
+		numbers = append(numbers, readColumn(rows, column, false))
+	}
+	return numbers
+}
+
+// ColumnsBottomToTop reads every column as a number starting from the leftmost one, with the most
+// significant digit at the bottom
+type ColumnsBottomToTop struct{}
+
+func (ColumnsBottomToTop) ReadNumbers(rows [][]rune) []string {
+	numbers := make([]string, 0, problemWidth(rows))
+	for column := range problemWidth(rows) {
+		numbers = append(numbers, readColumn(rows, column, true))
+	}
+	return numbers
+}
+
+// Diagonals reads every diagonal running down and to the right as a number with the most significant digit
+// at the top, starting from the diagonal in the bottom left corner and ending with the one in the top right
+type Diagonals struct{}
+
+func (Diagonals) ReadNumbers(rows [][]rune) []string {
+	width := problemWidth(rows)
+	numbers := make([]string, 0, len(rows)+width)
+	// a diagonal is the cells whose column is the row plus the offset
+	for offset := -(len(rows) - 1); offset < width; offset++ {
+		var builder strings.Builder
+		for row := max(0, -offset); row < len(rows) && row+offset < width; row++ {
+			if char := rows[row][row+offset]; char != ' ' {
+				builder.WriteRune(char)
+			}
+		}
+		numbers = append(numbers, builder.String())
+	}
+	return numbers
+}
+
+func problemWidth(rows [][]rune) int {
+	if len(rows) == 0 {
+		return 0
+	}
+	return len(rows[0])
+}
+
+// readColumn joins the characters of the column that are not spaces, from the top or from the bottom
+func readColumn(rows [][]rune, column int, fromBottom bool) string {
+	var builder strings.Builder
+	for idx := range rows {
+		row := rows[idx]
+		if fromBottom {
+			row = rows[len(rows)-1-idx]
+		}
+		if char := row[column]; char != ' ' {
+			builder.WriteRune(char)
+		}
+	}
+	return builder.String()
+}
+
+// InterpreterRegistry is the set of interpreters that can be picked by name
+type InterpreterRegistry struct {
+	interpreters map[string]PuzzleInterpreter
+}
+
+func NewInterpreterRegistry() *InterpreterRegistry {
+	return &InterpreterRegistry{
+		interpreters: make(map[string]PuzzleInterpreter),
+	}
+}
+
+// Register adds the interpreter under the name, a name can only be registered once
+func (r *InterpreterRegistry) Register(name string, interpreter PuzzleInterpreter) error {
+	if name == "" {
+		return fmt.Errorf("interpreter name can not be empty")
+	}
+	if interpreter == nil {
+		return fmt.Errorf("interpreter %s has nothing to read numbers with", name)
+	}
+	if _, ok := r.interpreters[name]; ok {
+		return fmt.Errorf("interpreter %s is already registered", name)
+	}
+	r.interpreters[name] = interpreter
+	return nil
+}
+
+func (r *InterpreterRegistry) Lookup(name string) (PuzzleInterpreter, bool) {
+	interpreter, ok := r.interpreters[name]
+	return interpreter, ok
+}
+
+// Names are the registered names in alphabetical order
+func (r *InterpreterRegistry) Names() []string {
+	names := make([]string, 0, len(r.interpreters))
+	for name := range r.interpreters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
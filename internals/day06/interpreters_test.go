@@ -0,0 +1,125 @@
+package day06
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func toRows(lines ...string) [][]rune {
+	rows := make([][]rune, 0, len(lines))
+	for _, line := range lines {
+		rows = append(rows, []rune(line))
+	}
+	return rows
+}
+
+func TestPuzzleInterpreter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		interpreter PuzzleInterpreter
+		rows        [][]rune
+		expected    []string
+	}{
+		{interpreter: RowsTopToBottom{}, rows: toRows("123", " 45", "  6"), expected: []string{"123", "45", "6"}},
+		{interpreter: ReversedRows{}, rows: toRows("123", " 45", "  6"), expected: []string{"321", "54", "6"}},
+		{interpreter: ReversedRows{}, rows: toRows("-120", "  +7", "    "), expected: []string{"-021", "+7", ""}},
+		{interpreter: ColumnsLeftToRight{}, rows: toRows("123", " 45", "  6"), expected: []string{"1", "24", "356"}},
+		{interpreter: ColumnsRightToLeft{}, rows: toRows("123", " 45", "  6"), expected: []string{"356", "24", "1"}},
+		{interpreter: ColumnsBottomToTop{}, rows: toRows("123", " 45", "  6"), expected: []string{"1", "42", "653"}},
+		{interpreter: Diagonals{}, rows: toRows("123", " 45", "  6"), expected: []string{"", "", "146", "25", "3"}},
+		{interpreter: Diagonals{}, rows: toRows("12", "34", "56"), expected: []string{"5", "36", "14", "2"}},
+		{interpreter: ColumnsLeftToRight{}, rows: toRows(), expected: []string{}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Reads %q with %T", tt.rows, tt.interpreter), func(t *testing.T) {
+			t.Parallel()
+			//when
+			numbers := tt.interpreter.ReadNumbers(tt.rows)
+
+			//then
+			assert.Equal(t, tt.expected, numbers)
+		})
+	}
+}
+
+func TestDay6Solver_interpreters(t *testing.T) {
+	t.Parallel()
+
+	input := "123\n 45\n  6\n-  "
+
+	testCases := []struct {
+		interpreter PuzzleInterpreter
+		expected    int64
+	}{
+		{interpreter: RowsTopToBottom{}, expected: 123 - 45 - 6},
+		{interpreter: ReversedRows{}, expected: 321 - 54 - 6},
+		{interpreter: ColumnsLeftToRight{}, expected: 1 - 24 - 356},
+		{interpreter: ColumnsRightToLeft{}, expected: 356 - 24 - 1},
+		{interpreter: ColumnsBottomToTop{}, expected: 1 - 42 - 653},
+		{interpreter: Diagonals{}, expected: 146 - 25 - 3},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Solves the worksheet with %T", tt.interpreter), func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, _ := NewDay6Solver(nil)
+
+			//when
+			solution, err := solver.Solve(context.Background(), strings.NewReader(input), tt.interpreter)
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(tt.expected), solution)
+		})
+	}
+
+	t.Run("Fails without an interpreter", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay6Solver(nil)
+
+		//when
+		_, err := solver.Solve(context.Background(), strings.NewReader(input), nil)
+
+		//then
+		assert.EqualError(t, err, "missing puzzle interpreter")
+	})
+}
+
+func TestInterpreterRegistry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Registers interpreters by name", func(t *testing.T) {
+		t.Parallel()
+		//given
+		registry := NewInterpreterRegistry()
+
+		//when
+		humanErr := registry.Register("human", HumanMath)
+		diagonalErr := registry.Register("diagonal", Diagonals{})
+		duplicateErr := registry.Register("human", ReversedRows{})
+		emptyErr := registry.Register("", ReversedRows{})
+		nilErr := registry.Register("reversed", nil)
+
+		//then
+		assert.NoError(t, humanErr)
+		assert.NoError(t, diagonalErr)
+		assert.EqualError(t, duplicateErr, "interpreter human is already registered")
+		assert.EqualError(t, emptyErr, "interpreter name can not be empty")
+		assert.EqualError(t, nilErr, "interpreter reversed has nothing to read numbers with")
+		assert.Equal(t, []string{"diagonal", "human"}, registry.Names())
+		interpreter, ok := registry.Lookup("human")
+		assert.True(t, ok)
+		assert.Equal(t, HumanMath, interpreter)
+		_, ok = registry.Lookup("reversed")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,254 @@
+package day06
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+type Associativity int
+
+const (
+	// LeftAssociative folds the numbers from the first one, a - b - c is (a - b) - c
+	LeftAssociative Associativity = iota
+	// RightAssociative folds the numbers from the last one, a ^ b ^ c is a ^ (b ^ c)
+	RightAssociative
+)
+
+// ErrOverflow is returned by Apply when the result does not fit into an int, the numbers are then folded again with ApplyBig
+var ErrOverflow = errors.New("integer overflow")
+
+// Operator combines the numbers of a problem two at a time
+type Operator struct {
+	Symbol        string
+	Associativity Associativity
+	Apply         func(a int, b int) (int, error)
+	// ApplyBig is only used once Apply overflowed or a number did not fit into an int, without it an overflow is an error
+	ApplyBig func(a *big.Int, b *big.Int) (*big.Int, error)
+}
+
+func (o Operator) fold(numbers []int) (int, error) {
+	return foldNumbers(o.Symbol, o.Associativity, numbers, o.Apply)
+}
+
+func (o Operator) foldBig(numbers []*big.Int) (*big.Int, error) {
+	if o.ApplyBig == nil {
+		return nil, fmt.Errorf("operator %s overflowed and has no big integer arithmetic: %w", o.Symbol, ErrOverflow)
+	}
+	return foldNumbers(o.Symbol, o.Associativity, numbers, o.ApplyBig)
+}
+
+func foldNumbers[T any](symbol string, associativity Associativity, numbers []T, apply func(a T, b T) (T, error)) (T, error) {
+	var result T
+	if len(numbers) == 0 {
+		return result, fmt.Errorf("operator %s needs at least one number", symbol)
+	}
+	if associativity == RightAssociative {
+		result = numbers[len(numbers)-1]
+		for i := len(numbers) - 2; i >= 0; i-- {
+			var err error
+			if result, err = apply(numbers[i], result); err != nil {
+				return result, err
+			}
+		}
+		return result, nil
+	}
+	result = numbers[0]
+	for _, number := range numbers[1:] {
+		var err error
+		if result, err = apply(result, number); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// OperatorRegistry is the set of operators that can show up on the operator line
+type OperatorRegistry struct {
+	operators map[string]Operator
+}
+
+func NewOperatorRegistry() *OperatorRegistry {
+	return &OperatorRegistry{
+		operators: make(map[string]Operator),
+	}
+}
+
+// Register adds the operator, a symbol can only be registered once and can not contain spaces
+// as the spaces separate the operators of the operator line
+func (r *OperatorRegistry) Register(operator Operator) error {
+	if operator.Symbol == "" || strings.ContainsAny(operator.Symbol, " \t") {
+		return fmt.Errorf("operator symbol '%s' has to be non empty and without spaces", operator.Symbol)
+	}
+	if operator.Apply == nil {
+		return fmt.Errorf("operator %s has nothing to apply", operator.Symbol)
+	}
+	if _, ok := r.operators[operator.Symbol]; ok {
+		return fmt.Errorf("operator %s is already registered", operator.Symbol)
+	}
+	r.operators[operator.Symbol] = operator
+	return nil
+}
+
+func (r *OperatorRegistry) Lookup(symbol string) (Operator, bool) {
+	operator, ok := r.operators[symbol]
+	return operator, ok
+}
+
+// isOperatorLine is true when every word of the line is a registered operator
+func (r *OperatorRegistry) isOperatorLine(line string) bool {
+	symbols := strings.Fields(line)
+	if len(symbols) == 0 {
+		return false
+	}
+	for _, symbol := range symbols {
+		if _, ok := r.operators[symbol]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultOperators has the operators of the puzzle (+ and *) together with -, /, %, min, max and ^
+func DefaultOperators() *OperatorRegistry {
+	registry := NewOperatorRegistry()
+	for _, operator := range []Operator{
+		{Symbol: "+", Associativity: LeftAssociative, Apply: add, ApplyBig: func(a *big.Int, b *big.Int) (*big.Int, error) { return new(big.Int).Add(a, b), nil }},
+		{Symbol: "*", Associativity: LeftAssociative, Apply: multiply, ApplyBig: func(a *big.Int, b *big.Int) (*big.Int, error) { return new(big.Int).Mul(a, b), nil }},
+		{Symbol: "-", Associativity: LeftAssociative, Apply: subtract, ApplyBig: func(a *big.Int, b *big.Int) (*big.Int, error) { return new(big.Int).Sub(a, b), nil }},
+		{Symbol: "/", Associativity: LeftAssociative, Apply: divide, ApplyBig: divideBig},
+		{Symbol: "%", Associativity: LeftAssociative, Apply: modulo, ApplyBig: moduloBig},
+		{Symbol: "min", Associativity: LeftAssociative, Apply: func(a int, b int) (int, error) { return min(a, b), nil }, ApplyBig: minBig},
+		{Symbol: "max", Associativity: LeftAssociative, Apply: func(a int, b int) (int, error) { return max(a, b), nil }, ApplyBig: maxBig},
+		{Symbol: "^", Associativity: RightAssociative, Apply: power, ApplyBig: powerBig},
+	} {
+		// the symbols above are all different so registering them can not fail
+		_ = registry.Register(operator)
+	}
+	return registry
+}
+
+func add(a int, b int) (int, error) {
+	sum := a + b
+	// the sum of two numbers with the same sign can only overflow into the opposite sign
+	if (a > 0 && b > 0 && sum < 0) || (a < 0 && b < 0 && sum >= 0) {
+		return 0, ErrOverflow
+	}
+	return sum, nil
+}
+
+func subtract(a int, b int) (int, error) {
+	difference := a - b
+	if (a >= 0 && b < 0 && difference < 0) || (a < 0 && b > 0 && difference >= 0) {
+		return 0, ErrOverflow
+	}
+	return difference, nil
+}
+
+func multiply(a int, b int) (int, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	// dividing back only catches the overflow when the product of the smallest int and -1 is ruled out first
+	if (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) || product/b != a {
+		return 0, ErrOverflow
+	}
+	return product, nil
+}
+
+// divide truncates towards zero the same way the division of Go does
+func divide(a int, b int) (int, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("division of %d by zero", a)
+	}
+	if a == math.MinInt && b == -1 {
+		return 0, ErrOverflow
+	}
+	return a / b, nil
+}
+
+func divideBig(a *big.Int, b *big.Int) (*big.Int, error) {
+	if b.Sign() == 0 {
+		return nil, fmt.Errorf("division of %s by zero", a)
+	}
+	return new(big.Int).Quo(a, b), nil
+}
+
+// modulo has the sign of the dividend the same way the remainder of Go does
+func modulo(a int, b int) (int, error) {
+	if b == 0 {
+		return 0, fmt.Errorf("modulo of %d by zero", a)
+	}
+	return a % b, nil
+}
+
+func moduloBig(a *big.Int, b *big.Int) (*big.Int, error) {
+	if b.Sign() == 0 {
+		return nil, fmt.Errorf("modulo of %s by zero", a)
+	}
+	return new(big.Int).Rem(a, b), nil
+}
+
+func minBig(a *big.Int, b *big.Int) (*big.Int, error) {
+	if a.Cmp(b) <= 0 {
+		return a, nil
+	}
+	return b, nil
+}
+
+func maxBig(a *big.Int, b *big.Int) (*big.Int, error) {
+	if a.Cmp(b) >= 0 {
+		return a, nil
+	}
+	return b, nil
+}
+
+// power raises the base by squaring, the exponent can not be negative as the result would not be an integer
+//
+// NOTE: Time complexity O(log exponent)
+func power(base int, exponent int) (int, error) {
+	if exponent < 0 {
+		return 0, fmt.Errorf("negative exponent %d", exponent)
+	}
+	result := 1
+	for exponent > 0 {
+		var err error
+		if exponent&1 == 1 {
+			if result, err = multiply(result, base); err != nil {
+				return 0, err
+			}
+		}
+		exponent >>= 1
+		// the base is only squared when there is a bit left to use it for, so the last squaring can not overflow needlessly
+		if exponent > 0 {
+			if base, err = multiply(base, base); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// maxPowerBits keeps a big power from eating up the memory, it is far beyond any answer a worksheet can expect
+const maxPowerBits = 1 << 20
+
+func powerBig(base *big.Int, exponent *big.Int) (*big.Int, error) {
+	if exponent.Sign() < 0 {
+		return nil, fmt.Errorf("negative exponent %s", exponent)
+	}
+	// 0, 1 and -1 stay small no matter the exponent, only its parity matters for them
+	if base.CmpAbs(big.NewInt(1)) <= 0 {
+		if exponent.Sign() == 0 {
+			return big.NewInt(1), nil
+		}
+		return new(big.Int).Exp(base, big.NewInt(2-int64(exponent.Bit(0))), nil), nil
+	}
+	// the result has at least exponent * (bit length - 1) bits, the limit is divided instead as the product can overflow
+	if exponent.Cmp(big.NewInt(int64(maxPowerBits/(base.BitLen()-1)))) > 0 {
+		return nil, fmt.Errorf("power %s ^ %s has more than %d bits", base, exponent, maxPowerBits)
+	}
+	return new(big.Int).Exp(base, exponent, nil), nil
+}
@@ -0,0 +1,234 @@
+package day06
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		symbol   string
+		numbers  []int
+		expected int
+		err      string
+	}{
+		{symbol: "+", numbers: []int{1, 2, 3}, expected: 6},
+		{symbol: "*", numbers: []int{2, 3, 4}, expected: 24},
+		{symbol: "-", numbers: []int{10, 3, 2}, expected: 5},
+		{symbol: "/", numbers: []int{100, 5, 3}, expected: 6},
+		{symbol: "/", numbers: []int{-7, 2}, expected: -3},
+		{symbol: "/", numbers: []int{7, 0}, err: "division of 7 by zero"},
+		{symbol: "%", numbers: []int{100, 7, 4}, expected: 2},
+		{symbol: "%", numbers: []int{-7, 3}, expected: -1},
+		{symbol: "%", numbers: []int{7, 0}, err: "modulo of 7 by zero"},
+		{symbol: "min", numbers: []int{4, -2, 9}, expected: -2},
+		{symbol: "max", numbers: []int{4, -2, 9}, expected: 9},
+		{symbol: "^", numbers: []int{2, 3, 2}, expected: 512},
+		{symbol: "^", numbers: []int{-3, 3}, expected: -27},
+		{symbol: "^", numbers: []int{5, 0}, expected: 1},
+		{symbol: "^", numbers: []int{2, -1}, err: "negative exponent -1"},
+		{symbol: "-", numbers: []int{42}, expected: 42},
+		{symbol: "+", numbers: []int{}, err: "operator + needs at least one number"},
+		{symbol: "+", numbers: []int{math.MaxInt, 1}, err: "integer overflow"},
+		{symbol: "+", numbers: []int{math.MinInt, -1}, err: "integer overflow"},
+		{symbol: "-", numbers: []int{math.MinInt, 1}, err: "integer overflow"},
+		{symbol: "-", numbers: []int{0, math.MinInt}, err: "integer overflow"},
+		{symbol: "*", numbers: []int{math.MaxInt/2 + 1, 2}, err: "integer overflow"},
+		{symbol: "*", numbers: []int{math.MinInt, -1}, err: "integer overflow"},
+		{symbol: "*", numbers: []int{math.MinInt / 2, 2}, expected: math.MinInt},
+		{symbol: "/", numbers: []int{math.MinInt, -1}, err: "integer overflow"},
+		{symbol: "^", numbers: []int{2, 63}, err: "integer overflow"},
+		{symbol: "^", numbers: []int{2, 62}, expected: 1 << 62},
+		{symbol: "^", numbers: []int{-2, 63}, expected: math.MinInt},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Folds %v with %s", tt.numbers, tt.symbol), func(t *testing.T) {
+			t.Parallel()
+			//given
+			operator, ok := DefaultOperators().Lookup(tt.symbol)
+			assert.True(t, ok)
+
+			//when
+			result, err := operator.fold(tt.numbers)
+
+			//then
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestOperatorRegistry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rejects invalid and duplicate operators", func(t *testing.T) {
+		t.Parallel()
+		//given
+		registry := DefaultOperators()
+		apply := func(a int, b int) (int, error) { return a, nil }
+
+		//when
+		duplicateErr := registry.Register(Operator{Symbol: "+", Apply: apply})
+		spaceErr := registry.Register(Operator{Symbol: "a b", Apply: apply})
+		emptyErr := registry.Register(Operator{Symbol: "", Apply: apply})
+		nilErr := registry.Register(Operator{Symbol: "first"})
+
+		//then
+		assert.EqualError(t, duplicateErr, "operator + is already registered")
+		assert.Error(t, spaceErr)
+		assert.Error(t, emptyErr)
+		assert.EqualError(t, nilErr, "operator first has nothing to apply")
+	})
+
+	t.Run("Recognises the operator line by the registered operators", func(t *testing.T) {
+		t.Parallel()
+		//given
+		registry := DefaultOperators()
+
+		//then
+		assert.True(t, registry.isOperatorLine("*   +  min ^  "))
+		assert.False(t, registry.isOperatorLine(" 12 -4  7"))
+		assert.False(t, registry.isOperatorLine("*  avg"))
+		assert.False(t, registry.isOperatorLine("   "))
+	})
+}
+
+func TestDay6Solver_operators(t *testing.T) {
+	t.Parallel()
+
+	input := "10 712 2 20\n 4 305 3  6\n-  min ^ % "
+
+	testCases := []struct {
+		interpreter PuzzleInterpreter
+		expected    int64
+	}{
+		// 10 - 4, min(712, 305), 2 ^ 3 and 20 % 6
+		{interpreter: HumanMath, expected: 6 + 305 + 8 + 2},
+		// 1 - 4, min(73, 10, 25), 23 and 2 % 6
+		{interpreter: CephalopodMath, expected: -3 + 10 + 23 + 2},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Solves a worksheet with the extended operators using interpreter %T", tt.interpreter), func(t *testing.T) {
+			t.Parallel()
+			//given
+			solver, _ := NewDay6Solver(nil)
+
+			//when
+			solution, err := solver.Solve(context.Background(), strings.NewReader(input), tt.interpreter)
+
+			//then
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(tt.expected), solution)
+		})
+	}
+
+	t.Run("Solves a worksheet with a custom operator", func(t *testing.T) {
+		t.Parallel()
+		//given
+		registry := NewOperatorRegistry()
+		_ = registry.Register(Operator{Symbol: "avg", Apply: func(a int, b int) (int, error) { return (a + b) / 2, nil }})
+		solver, _ := NewDay6Solver(nil, WithOperators(registry))
+
+		//when
+		solution, err := solver.Solve(context.Background(), strings.NewReader("10 \n20 \navg"), HumanMath)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(15), solution)
+	})
+
+	t.Run("Fails on a power too large to compute instead of computing it", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay6Solver(nil)
+
+		//when
+		_, err := solver.Solve(context.Background(), strings.NewReader("4\n4611686018427387904\n^"), HumanMath)
+
+		//then
+		assert.EqualError(t, err, "problem at columns 0-18: power 4 ^ 4611686018427387904 has more than 1048576 bits")
+	})
+
+	t.Run("Fails when a custom operator without big integer arithmetic overflows", func(t *testing.T) {
+		t.Parallel()
+		//given
+		registry := NewOperatorRegistry()
+		_ = registry.Register(Operator{Symbol: "add", Apply: add})
+		solver, _ := NewDay6Solver(nil, WithOperators(registry))
+
+		//when
+		_, err := solver.Solve(context.Background(), strings.NewReader("9223372036854775807\n                  1\nadd                "), HumanMath)
+
+		//then
+		assert.ErrorIs(t, err, ErrOverflow)
+	})
+}
+
+func TestOperator_foldBig(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		symbol   string
+		numbers  []string
+		expected string
+		err      string
+	}{
+		{symbol: "+", numbers: []string{"9223372036854775807", "1"}, expected: "9223372036854775808"},
+		{symbol: "*", numbers: []string{"99999999999999999999", "3"}, expected: "299999999999999999997"},
+		{symbol: "-", numbers: []string{"-9223372036854775808", "1"}, expected: "-9223372036854775809"},
+		{symbol: "/", numbers: []string{"-9223372036854775808", "-1"}, expected: "9223372036854775808"},
+		{symbol: "/", numbers: []string{"-7", "2"}, expected: "-3"},
+		{symbol: "/", numbers: []string{"7", "0"}, err: "division of 7 by zero"},
+		{symbol: "%", numbers: []string{"-7", "3"}, expected: "-1"},
+		{symbol: "%", numbers: []string{"7", "0"}, err: "modulo of 7 by zero"},
+		{symbol: "min", numbers: []string{"99999999999999999999", "-99999999999999999999"}, expected: "-99999999999999999999"},
+		{symbol: "max", numbers: []string{"99999999999999999999", "-99999999999999999999"}, expected: "99999999999999999999"},
+		{symbol: "^", numbers: []string{"2", "3", "2"}, expected: "512"},
+		{symbol: "^", numbers: []string{"2", "64"}, expected: "18446744073709551616"},
+		{symbol: "^", numbers: []string{"-1", "99999999999999999999"}, expected: "-1"},
+		{symbol: "^", numbers: []string{"0", "0"}, expected: "1"},
+		{symbol: "^", numbers: []string{"2", "-1"}, err: "negative exponent -1"},
+		{symbol: "^", numbers: []string{"2", "99999999999999999999"}, err: "power 2 ^ 99999999999999999999 has more than 1048576 bits"},
+		{symbol: "^", numbers: []string{"4", "4611686018427387904"}, err: "power 4 ^ 4611686018427387904 has more than 1048576 bits"},
+		{symbol: "^", numbers: []string{"2", "1048577"}, err: "power 2 ^ 1048577 has more than 1048576 bits"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("Folds %v with %s", tt.numbers, tt.symbol), func(t *testing.T) {
+			t.Parallel()
+			//given
+			operator, ok := DefaultOperators().Lookup(tt.symbol)
+			assert.True(t, ok)
+			numbers := []*big.Int{}
+			for _, numAsStr := range tt.numbers {
+				number, _ := new(big.Int).SetString(numAsStr, 10)
+				numbers = append(numbers, number)
+			}
+
+			//when
+			result, err := operator.foldBig(numbers)
+
+			//then
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result.String())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package day06
+
+import (
+	"bufio"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// ProblemBreakdown is how a single problem of the worksheet was read and what it came to, the columns of
+// the problem are Start up to but not including End
+type ProblemBreakdown struct {
+	Operator string     `json:"operator"`
+	Start    int        `json:"start"`
+	End      int        `json:"end"`
+	Operands []*big.Int `json:"operands"`
+	Result   *big.Int   `json:"result"`
+}
+
+type Worksheet struct {
+	NumberLines  []string
+	OperatorLine string
+	Problems     []ProblemBreakdown
+	Total        *big.Int
+}
+
+// Render prints the worksheet as it was read followed by a rule under every problem and the answers
+// starting at the first column of their problem. An answer that is wider than its problem would run
+// into the next one, so it is moved to a line below where it has room.
+func (w Worksheet) Render(writer io.Writer) error {
+	buffered := bufio.NewWriter(writer)
+	for _, line := range w.NumberLines {
+		buffered.WriteString(line)
+		buffered.WriteByte('\n')
+	}
+	buffered.WriteString(w.OperatorLine)
+	buffered.WriteByte('\n')
+
+	var rule strings.Builder
+	for _, problem := range w.Problems {
+		rule.WriteString(strings.Repeat(" ", problem.Start-rule.Len()))
+		rule.WriteString(strings.Repeat("-", problem.End-problem.Start))
+	}
+	buffered.WriteString(rule.String())
+	buffered.WriteByte('\n')
+
+	for _, line := range layoutAnswers(w.Problems) {
+		buffered.WriteString(line)
+		buffered.WriteByte('\n')
+	}
+	return buffered.Flush()
+}
+
+// layoutAnswers puts every answer on the first line where it does not touch the answer before it
+func layoutAnswers(problems []ProblemBreakdown) []string {
+	lines := []*strings.Builder{}
+	for _, problem := range problems {
+		answer := problem.Result.String()
+		var line *strings.Builder
+		for _, candidate := range lines {
+			// an answer needs a blank column between itself and the previous one to be told apart
+			if candidate.Len() < problem.Start {
+				line = candidate
+				break
+			}
+		}
+		if line == nil {
+			line = &strings.Builder{}
+			lines = append(lines, line)
+		}
+		line.WriteString(strings.Repeat(" ", problem.Start-line.Len()))
+		line.WriteString(answer)
+	}
+	rendered := make([]string, 0, len(lines))
+	for _, line := range lines {
+		rendered = append(rendered, line.String())
+	}
+	return rendered
+}
@@ -0,0 +1,105 @@
+package day06
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bigInts(numbers ...int64) []*big.Int {
+	bigNumbers := make([]*big.Int, 0, len(numbers))
+	for _, number := range numbers {
+		bigNumbers = append(bigNumbers, big.NewInt(number))
+	}
+	return bigNumbers
+}
+
+func TestDay6Solver_Breakdown(t *testing.T) {
+	t.Parallel()
+
+	input := "123 328  51 64 \n 45 64  387 23 \n  6 98  215 314\n*   +   *   +  "
+
+	t.Run("Breaks down the problems read by humans", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay6Solver(nil)
+
+		//when
+		worksheet, err := solver.Breakdown(context.Background(), strings.NewReader(input), HumanMath)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, []ProblemBreakdown{
+			{Operator: "*", Start: 0, End: 3, Operands: bigInts(123, 45, 6), Result: big.NewInt(33210)},
+			{Operator: "+", Start: 4, End: 7, Operands: bigInts(328, 64, 98), Result: big.NewInt(490)},
+			{Operator: "*", Start: 8, End: 11, Operands: bigInts(51, 387, 215), Result: big.NewInt(4243455)},
+			{Operator: "+", Start: 12, End: 15, Operands: bigInts(64, 23, 314), Result: big.NewInt(401)},
+		}, worksheet.Problems)
+		assert.Equal(t, big.NewInt(4277556), worksheet.Total)
+		assert.Equal(t, "*   +   *   +  ", worksheet.OperatorLine)
+		assert.Len(t, worksheet.NumberLines, 3)
+	})
+
+	t.Run("Breaks down the problems read by cephalopods column by column", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay6Solver(nil)
+
+		//when
+		worksheet, err := solver.Breakdown(context.Background(), strings.NewReader(input), CephalopodMath)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, []ProblemBreakdown{
+			{Operator: "*", Start: 0, End: 3, Operands: bigInts(1, 24, 356), Result: big.NewInt(8544)},
+			{Operator: "+", Start: 4, End: 7, Operands: bigInts(369, 248, 8), Result: big.NewInt(625)},
+			{Operator: "*", Start: 8, End: 11, Operands: bigInts(32, 581, 175), Result: big.NewInt(3253600)},
+			{Operator: "+", Start: 12, End: 15, Operands: bigInts(623, 431, 4), Result: big.NewInt(1058)},
+		}, worksheet.Problems)
+		assert.Equal(t, big.NewInt(3263827), worksheet.Total)
+	})
+}
+
+func TestWorksheet_Render(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Renders the answers under their problems and moves the wide ones down a line", func(t *testing.T) {
+		t.Parallel()
+		//given
+		solver, _ := NewDay6Solver(nil)
+		worksheet, _ := solver.Breakdown(context.Background(), strings.NewReader("123 328  51 64 \n 45 64  387 23 \n  6 98  215 314\n*   +   *   +  "), HumanMath)
+		var builder strings.Builder
+
+		//when
+		err := worksheet.Render(&builder)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, "123 328  51 64 \n 45 64  387 23 \n  6 98  215 314\n*   +   *   +  \n--- --- --- ---\n33210   4243455\n    490     401\n", builder.String())
+	})
+
+	t.Run("Renders the answers on a single line when they fit their problems", func(t *testing.T) {
+		t.Parallel()
+		//given
+		worksheet := Worksheet{
+			NumberLines:  []string{"12 3", " 4 5"},
+			OperatorLine: "+  *",
+			Problems: []ProblemBreakdown{
+				{Operator: "+", Start: 0, End: 2, Operands: bigInts(12, 4), Result: big.NewInt(16)},
+				{Operator: "*", Start: 3, End: 4, Operands: bigInts(3, 5), Result: big.NewInt(15)},
+			},
+			Total: big.NewInt(31),
+		}
+		var builder strings.Builder
+
+		//when
+		err := worksheet.Render(&builder)
+
+		//then
+		assert.NoError(t, err)
+		assert.Equal(t, "12 3\n 4 5\n+  *\n-- -\n16 15\n", builder.String())
+	})
+}
@@ -0,0 +1,173 @@
+// Package intervals is range algebra over integers, the intervals are kept as closed intervals
+// and half-open intervals are converted to closed ones when they are created
+package intervals
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"math/bits"
+	"slices"
+	"sort"
+)
+
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Interval is the closed interval [Min, Max]
+type Interval[T Integer] struct {
+	Min T `json:"min"`
+	Max T `json:"max"`
+}
+
+// Closed is the interval [min, max]
+func Closed[T Integer](min T, max T) (Interval[T], error) {
+	if min > max {
+		return Interval[T]{}, fmt.Errorf("interval [%v, %v] has its min above its max", min, max)
+	}
+	return Interval[T]{Min: min, Max: max}, nil
+}
+
+// HalfOpen is the interval [start, end), the second return value is false when the interval is empty
+func HalfOpen[T Integer](start T, end T) (Interval[T], bool) {
+	if end <= start {
+		return Interval[T]{}, false
+	}
+	return Interval[T]{Min: start, Max: end - 1}, true
+}
+
+func (i Interval[T]) Contains(value T) bool {
+	return i.Min <= value && value <= i.Max
+}
+
+// ContainsInterval tells if every value of the other interval is in the interval
+func (i Interval[T]) ContainsInterval(other Interval[T]) bool {
+	return i.Min <= other.Min && other.Max <= i.Max
+}
+
+func (i Interval[T]) Overlaps(other Interval[T]) bool {
+	return i.Min <= other.Max && other.Min <= i.Max
+}
+
+// Intersection is the overlap of the two intervals, the second return value is false when they do not overlap
+func (i Interval[T]) Intersection(other Interval[T]) (Interval[T], bool) {
+	if !i.Overlaps(other) {
+		return Interval[T]{}, false
+	}
+	return Interval[T]{Min: max(i.Min, other.Min), Max: min(i.Max, other.Max)}, true
+}
+
+// End is the exclusive end of the interval when it is seen as half-open, the second return value is false
+// when the interval ends at the largest value of T and so the end can not be represented
+func (i Interval[T]) End() (T, bool) {
+	return next(i.Max)
+}
+
+// Cardinality is the number of values in the interval, the second return value is false when it does not fit
+// into an uint64, which only happens for the interval covering every value of a 64 bit type
+func (i Interval[T]) Cardinality() (uint64, bool) {
+	// the subtraction wraps around for signed types in the same way the distance between the two values does
+	distance := uint64(i.Max) - uint64(i.Min)
+	if distance == ^uint64(0) {
+		return 0, false
+	}
+	return distance + 1, true
+}
+
+// Values iterates over every value of the interval in ascending order
+func (i Interval[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for value := i.Min; ; value++ {
+			if !yield(value) || value == i.Max {
+				return
+			}
+		}
+	}
+}
+
+func (i Interval[T]) String() string {
+	return fmt.Sprintf("[%v, %v]", i.Min, i.Max)
+}
+
+// Compare orders the intervals by their min and then by their max
+func Compare[T Integer](a Interval[T], b Interval[T]) int {
+	return cmp.Or(cmp.Compare(a.Min, b.Min), cmp.Compare(a.Max, b.Max))
+}
+
+// Merge sorts the intervals and joins the overlapping ones, with coalesceAdjacent intervals that
+// only touch (e.g. [1, 3] and [4, 6]) are joined as well. The input is left untouched.
+//
+// NOTE: Time complexity O(n log n)
+func Merge[T Integer](intervals []Interval[T], coalesceAdjacent bool) []Interval[T] {
+	merged := make([]Interval[T], 0, len(intervals))
+	if len(intervals) == 0 {
+		return merged
+	}
+	sorted := slices.Clone(intervals)
+	slices.SortFunc(sorted, Compare)
+	current := sorted[0]
+	for _, interval := range sorted[1:] {
+		isOverlapping := interval.Min <= current.Max
+		afterCurrent, hasAfter := next(current.Max)
+		isAdjacent := coalesceAdjacent && hasAfter && interval.Min == afterCurrent
+		if isOverlapping || isAdjacent {
+			current.Max = max(current.Max, interval.Max)
+			continue
+		}
+		merged = append(merged, current)
+		current = interval
+	}
+	return append(merged, current)
+}
+
+// ContainsSorted tells if any of the intervals contains the value, the intervals have to be sorted
+// and must not overlap, which is what Merge returns
+//
+// NOTE: Time complexity O(log n)
+func ContainsSorted[T Integer](sorted []Interval[T], value T) bool {
+	_, found := SearchSorted(sorted, value)
+	return found
+}
+
+// SearchSorted is the index of the interval that contains the value, the intervals have to be sorted
+// and must not overlap. The second return value is false when none of the intervals contains the value.
+//
+// NOTE: Time complexity O(log n)
+func SearchSorted[T Integer](sorted []Interval[T], value T) (int, bool) {
+	// the first interval that does not end before the value is the only one that can contain it
+	idx := sort.Search(len(sorted), func(i int) bool {
+		return sorted[i].Max >= value
+	})
+	return idx, idx < len(sorted) && sorted[idx].Min <= value
+}
+
+// Cardinality is the number of values in the intervals, the intervals must not overlap.
+// The second return value is false when the count does not fit into an uint64.
+func Cardinality[T Integer](disjoint []Interval[T]) (uint64, bool) {
+	total := uint64(0)
+	for _, interval := range disjoint {
+		count, ok := interval.Cardinality()
+		if !ok {
+			return 0, false
+		}
+		var carry uint64
+		total, carry = bits.Add64(total, count, 0)
+		if carry != 0 {
+			return 0, false
+		}
+	}
+	return total, true
+}
+
+// next is the value after the given one, the second return value is false when the value is the largest one of T
+func next[T Integer](value T) (T, bool) {
+	after := value + 1
+	return after, after > value
+}
+
+// previous is the value before the given one, the second return value is false when the value is the smallest one of T
+func previous[T Integer](value T) (T, bool) {
+	before := value - 1
+	return before, before < value
+}
@@ -0,0 +1,172 @@
+package intervals
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterval(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rejects a closed interval with its min above its max", func(t *testing.T) {
+		t.Parallel()
+		//when
+		_, err := Closed(5, 3)
+
+		//then
+		assert.EqualError(t, err, "interval [5, 3] has its min above its max")
+	})
+
+	t.Run("Converts a half-open interval to a closed one", func(t *testing.T) {
+		t.Parallel()
+		//when
+		interval, ok := HalfOpen[uint8](3, 7)
+		_, isEmptyOk := HalfOpen[uint8](7, 7)
+
+		//then
+		assert.True(t, ok)
+		assert.False(t, isEmptyOk)
+		assert.Equal(t, Interval[uint8]{Min: 3, Max: 6}, interval)
+		end, hasEnd := interval.End()
+		assert.True(t, hasEnd)
+		assert.Equal(t, uint8(7), end)
+		_, hasEnd = Interval[uint8]{Min: 3, Max: 255}.End()
+		assert.False(t, hasEnd)
+	})
+
+	t.Run("Counts the values of intervals at the limits of their type", func(t *testing.T) {
+		t.Parallel()
+		//when
+		signed, signedOk := Interval[int8]{Min: math.MinInt8, Max: math.MaxInt8}.Cardinality()
+		unsigned, unsignedOk := Interval[uint64]{Min: 0, Max: math.MaxUint64 - 1}.Cardinality()
+		_, fullOk := Interval[int64]{Min: math.MinInt64, Max: math.MaxInt64}.Cardinality()
+		_, sumOk := Cardinality([]Interval[uint64]{{Min: 0, Max: math.MaxUint64 - 1}, {Min: math.MaxUint64, Max: math.MaxUint64}})
+
+		//then
+		assert.True(t, signedOk)
+		assert.Equal(t, uint64(256), signed)
+		assert.True(t, unsignedOk)
+		assert.Equal(t, uint64(math.MaxUint64), unsigned)
+		assert.False(t, fullOk)
+		assert.False(t, sumOk)
+	})
+
+	t.Run("Iterates over every value up to the largest value of the type", func(t *testing.T) {
+		t.Parallel()
+		//when
+		values := slices.Collect(Interval[uint8]{Min: 253, Max: 255}.Values())
+
+		//then
+		assert.Equal(t, []uint8{253, 254, 255}, values)
+	})
+
+	t.Run("Merges overlapping intervals and optionally adjacent ones", func(t *testing.T) {
+		t.Parallel()
+		//given
+		intervals := []Interval[int]{{Min: 12, Max: 18}, {Min: 3, Max: 5}, {Min: 10, Max: 14}, {Min: 6, Max: 8}, {Min: 16, Max: 20}}
+
+		//when
+		merged := Merge(intervals, false)
+		coalesced := Merge(intervals, true)
+
+		//then
+		assert.Equal(t, []Interval[int]{{Min: 3, Max: 5}, {Min: 6, Max: 8}, {Min: 10, Max: 20}}, merged)
+		assert.Equal(t, []Interval[int]{{Min: 3, Max: 8}, {Min: 10, Max: 20}}, coalesced)
+		assert.Equal(t, Interval[int]{Min: 12, Max: 18}, intervals[0])
+		assert.Empty(t, Merge([]Interval[int]{}, true))
+	})
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	// every operation is checked against a plain set of values, int8 is small enough to list every value of it
+	everyValue := Interval[int8]{Min: math.MinInt8, Max: math.MaxInt8}
+
+	newRandomSet := func(random *rand.Rand) (Set[int8], map[int8]bool) {
+		intervals := make([]Interval[int8], 0)
+		values := make(map[int8]bool)
+		for range random.IntN(8) {
+			start := int8(random.IntN(256) - 128)
+			interval := Interval[int8]{Min: start, Max: int8(min(int(start)+random.IntN(40), math.MaxInt8))}
+			intervals = append(intervals, interval)
+			for value := range interval.Values() {
+				values[value] = true
+			}
+		}
+		return NewSet(intervals...), values
+	}
+
+	assertSet := func(t *testing.T, expected func(value int8) bool, set Set[int8]) {
+		t.Helper()
+		count := uint64(0)
+		for value := range everyValue.Values() {
+			assert.Equal(t, expected(value), set.Contains(value), "value %d", value)
+			if expected(value) {
+				count++
+			}
+		}
+		cardinality, ok := set.Cardinality()
+		assert.True(t, ok)
+		assert.Equal(t, count, cardinality)
+		intervals := set.Intervals()
+		for i := 1; i < len(intervals); i++ {
+			// the intervals have to be sorted and must not touch each other
+			assert.Greater(t, int(intervals[i].Min), int(intervals[i-1].Max)+1)
+		}
+	}
+
+	for seed := range uint64(40) {
+		t.Run(fmt.Sprintf("Matches the set operations of plain sets for seed %d", seed), func(t *testing.T) {
+			t.Parallel()
+			//given
+			random := rand.New(rand.NewPCG(41, seed))
+			a, aValues := newRandomSet(random)
+			b, bValues := newRandomSet(random)
+			bounds := Interval[int8]{Min: int8(random.IntN(128) - 128), Max: int8(random.IntN(128))}
+
+			//when
+			union := a.Union(b)
+			intersection := a.Intersection(b)
+			difference := a.Difference(b)
+			complement := a.Complement(bounds)
+
+			//then
+			assertSet(t, func(v int8) bool { return aValues[v] }, a)
+			assertSet(t, func(v int8) bool { return aValues[v] || bValues[v] }, union)
+			assertSet(t, func(v int8) bool { return aValues[v] && bValues[v] }, intersection)
+			assertSet(t, func(v int8) bool { return aValues[v] && !bValues[v] }, difference)
+			assertSet(t, func(v int8) bool { return bounds.Contains(v) && !aValues[v] }, complement)
+			values := slices.Collect(a.Values())
+			assert.Len(t, values, len(aValues))
+			assert.True(t, slices.IsSorted(values))
+			for interval := range b.All() {
+				assert.True(t, b.ContainsInterval(interval))
+				isContained := true
+				for value := range interval.Values() {
+					isContained = isContained && aValues[value]
+				}
+				assert.Equal(t, isContained, a.ContainsInterval(interval), "interval %s", interval)
+			}
+		})
+	}
+
+	t.Run("Complements a set reaching the limits of its type", func(t *testing.T) {
+		t.Parallel()
+		//given
+		set := NewSet(Interval[uint8]{Min: 0, Max: 3}, Interval[uint8]{Min: 250, Max: 255})
+
+		//when
+		complement := set.Complement(Interval[uint8]{Min: 0, Max: 255})
+		empty := set.Union(complement).Complement(Interval[uint8]{Min: 0, Max: 255})
+
+		//then
+		assert.Equal(t, []Interval[uint8]{{Min: 4, Max: 249}}, complement.Intervals())
+		assert.True(t, empty.IsEmpty())
+	})
+}
@@ -0,0 +1,145 @@
+package intervals
+
+import (
+	"iter"
+	"slices"
+)
+
+// Set is a set of integers kept as sorted intervals that neither overlap nor touch each other,
+// so every set has exactly one representation. Sets are never modified, the operations return new sets.
+type Set[T Integer] struct {
+	intervals []Interval[T]
+}
+
+// NewSet is the union of the intervals
+//
+// NOTE: Time complexity O(n log n)
+func NewSet[T Integer](intervals ...Interval[T]) Set[T] {
+	return Set[T]{intervals: Merge(intervals, true)}
+}
+
+func (s Set[T]) IsEmpty() bool {
+	return len(s.intervals) == 0
+}
+
+// Len is the number of intervals in the set
+func (s Set[T]) Len() int {
+	return len(s.intervals)
+}
+
+// Intervals is a copy of the intervals of the set in ascending order
+func (s Set[T]) Intervals() []Interval[T] {
+	return slices.Clone(s.intervals)
+}
+
+// All iterates over the intervals of the set in ascending order
+func (s Set[T]) All() iter.Seq[Interval[T]] {
+	return slices.Values(s.intervals)
+}
+
+// Values iterates over every value of the set in ascending order
+func (s Set[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, interval := range s.intervals {
+			for value := range interval.Values() {
+				if !yield(value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Contains tells if the value is in the set
+//
+// NOTE: Time complexity O(log n)
+func (s Set[T]) Contains(value T) bool {
+	return ContainsSorted(s.intervals, value)
+}
+
+// ContainsInterval tells if every value of the interval is in the set
+//
+// NOTE: Time complexity O(log n)
+func (s Set[T]) ContainsInterval(interval Interval[T]) bool {
+	// as the intervals of the set never touch, the interval has to fit into the one holding its min
+	idx, found := slices.BinarySearchFunc(s.intervals, interval.Min, func(candidate Interval[T], value T) int {
+		switch {
+		case candidate.Max < value:
+			return -1
+		case candidate.Min > value:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return found && s.intervals[idx].ContainsInterval(interval)
+}
+
+// Cardinality is the number of values in the set, the second return value is false when it does not fit into an uint64
+func (s Set[T]) Cardinality() (uint64, bool) {
+	return Cardinality(s.intervals)
+}
+
+// Union is every value that is in either of the sets
+//
+// NOTE: Time complexity O((n + m) log(n + m))
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	return NewSet(slices.Concat(s.intervals, other.intervals)...)
+}
+
+// Intersection is every value that is in both of the sets
+//
+// NOTE: Time complexity O(n + m)
+func (s Set[T]) Intersection(other Set[T]) Set[T] {
+	intersection := make([]Interval[T], 0)
+	i, j := 0, 0
+	for i < len(s.intervals) && j < len(other.intervals) {
+		if overlap, ok := s.intervals[i].Intersection(other.intervals[j]); ok {
+			intersection = append(intersection, overlap)
+		}
+		// the interval that ends first can not overlap anything else of the other set
+		if s.intervals[i].Max < other.intervals[j].Max {
+			i++
+		} else {
+			j++
+		}
+	}
+	return Set[T]{intervals: intersection}
+}
+
+// Difference is every value of the set that is not in the other set
+//
+// NOTE: Time complexity O(n + m)
+func (s Set[T]) Difference(other Set[T]) Set[T] {
+	if s.IsEmpty() {
+		return s
+	}
+	span := Interval[T]{Min: s.intervals[0].Min, Max: s.intervals[len(s.intervals)-1].Max}
+	return s.Intersection(other.Complement(span))
+}
+
+// Complement is every value within the bounds that is not in the set
+//
+// NOTE: Time complexity O(n)
+func (s Set[T]) Complement(bounds Interval[T]) Set[T] {
+	complement := make([]Interval[T], 0)
+	// start is where the next gap can begin, hasStart is false once the set reached the largest value of T
+	start, hasStart := bounds.Min, true
+	for _, interval := range s.intervals {
+		if !hasStart || start > bounds.Max {
+			break
+		}
+		if interval.Max < start {
+			continue
+		}
+		if interval.Min > start {
+			gapEnd, _ := previous(interval.Min)
+			complement = append(complement, Interval[T]{Min: start, Max: min(gapEnd, bounds.Max)})
+		}
+		start, hasStart = next(interval.Max)
+	}
+	if hasStart && start <= bounds.Max {
+		complement = append(complement, Interval[T]{Min: start, Max: bounds.Max})
+	}
+	return Set[T]{intervals: complement}
+}